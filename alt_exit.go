@@ -23,32 +23,107 @@ package logrus
 // CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
 
 import (
-	"fmt"
-	"os"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
-var handlers = []func(){}
+// defaultExitTimeout bounds how long Exit waits for the exit handlers by default
+const defaultExitTimeout = 10 * time.Second
 
-func runHandler(handler func()) {
-	defer func() {
-		if err := recover(); err != nil {
-			fmt.Fprintln(os.Stderr, "Error: Logrus exit handler error:", err)
+// exitHandler is a handler registered with RegisterExitHandler and its variants
+type exitHandler struct {
+	fn       func()
+	priority int
+	id       uint64
+}
+
+var (
+	// handlers are the registered exit handlers, in the order they run
+	handlers    = []exitHandler{}
+	handlersMux sync.Mutex
+	handlersID  uint64
+
+	// exitTimeout is the time.Duration Exit waits for the handlers (see SetExitTimeout)
+	exitTimeout = int64(defaultExitTimeout)
+
+	// exitRunning is 1 while the handlers run, so they don't run again if a handler calls Exit
+	exitRunning int32
+)
+
+// ExitHandle is returned by the functions registering an exit handler, to deregister it.
+type ExitHandle struct {
+	id uint64
+}
+
+// Deregister removes the exit handler, which won't be run by Exit anymore. It does nothing if
+// the handler was already removed.
+func (h ExitHandle) Deregister() {
+	handlersMux.Lock()
+	defer handlersMux.Unlock()
+	for i, handler := range handlers {
+		if handler.id == h.id {
+			handlers = append(handlers[:i:i], handlers[i+1:]...)
+			return
 		}
-	}()
+	}
+}
 
+func runHandler(logger *Logger, handler func()) {
+	defer logger.Recover(RecoverOptions{Message: "exit handler panicked"})
 	handler()
 }
 
-func runHandlers() {
-	for _, handler := range handlers {
-		runHandler(handler)
+// runHandlers runs the exit handlers, for at most the exit timeout. The panics and the timeout
+// are reported with the logger.
+func runHandlers(logger *Logger) {
+	if !atomic.CompareAndSwapInt32(&exitRunning, 0, 1) {
+		return
+	}
+	defer atomic.StoreInt32(&exitRunning, 0)
+
+	handlersMux.Lock()
+	snapshot := make([]exitHandler, len(handlers))
+	copy(snapshot, handlers)
+	handlersMux.Unlock()
+	if len(snapshot) == 0 {
+		return
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for _, handler := range snapshot {
+			runHandler(logger, handler.fn)
+		}
+	}()
+
+	timeout := time.Duration(atomic.LoadInt64(&exitTimeout))
+	if timeout <= 0 {
+		<-done
+		return
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-done:
+	case <-timer.C:
+		logger.AsError().With(Duration("timeout", timeout)).Write("exit handlers timed out")
 	}
 }
 
-// Exit runs all the Logrus atexit handlers and then terminates the program using os.Exit(code)
+// Exit runs all the Logrus atexit handlers, waits for the asynchronous loggers to write
+// their queued entries and then terminates the program using os.Exit(code)
 func Exit(code int) {
-	runHandlers()
-	os.Exit(code)
+	exit(std, code)
+}
+
+// SetExitTimeout sets how long Exit waits for the exit handlers to return, 10 seconds by
+// default. Once the timeout has elapsed, the program exits even if a handler hangs. Zero or a
+// negative timeout waits as long as the handlers run.
+func SetExitTimeout(timeout time.Duration) {
+	atomic.StoreInt64(&exitTimeout, int64(timeout))
 }
 
 // RegisterExitHandler adds a Logrus Exit handler, call logrus.Exit to invoke
@@ -59,6 +134,50 @@ func Exit(code int) {
 // message but also needs to gracefully shutdown. An example usecase could be
 // closing database connections, or sending a alert that the application is
 // closing.
-func RegisterExitHandler(handler func()) {
-	handlers = append(handlers, handler)
+//
+// The handlers run in the order they were registered, after the ones with a higher priority
+// (see RegisterExitHandlerWithPriority). The panics of the handlers are recovered and logged
+// by the standard Logger. It's safe to register the handlers from several goroutines.
+func RegisterExitHandler(handler func()) ExitHandle {
+	return addExitHandler(handler, 0, false)
+}
+
+// RegisterExitHandlerWithPriority adds an exit handler which runs before the handlers with a
+// lower priority, and after the ones with a higher priority. The handlers registered with
+// RegisterExitHandler and DeferExitHandler have a zero priority.
+func RegisterExitHandlerWithPriority(handler func(), priority int) ExitHandle {
+	return addExitHandler(handler, priority, false)
+}
+
+// DeferExitHandler adds an exit handler which runs before the handlers of the same priority
+// registered before it, like the deferred functions do. It's useful to release the resources
+// in the reverse order they were acquired:
+//
+//	db := openDB()
+//	logrus.DeferExitHandler(db.Close)
+//	cache := openCache(db)
+//	logrus.DeferExitHandler(cache.Flush) // runs before db.Close
+func DeferExitHandler(handler func()) ExitHandle {
+	return addExitHandler(handler, 0, true)
+}
+
+func addExitHandler(fn func(), priority int, first bool) ExitHandle {
+	handlersMux.Lock()
+	defer handlersMux.Unlock()
+	handlersID++
+	handler := exitHandler{fn: fn, priority: priority, id: handlersID}
+
+	// The handlers are sorted by decreasing priority. The new handler goes before or after the
+	// handlers of the same priority.
+	i := sort.Search(len(handlers), func(i int) bool {
+		if first {
+			return handlers[i].priority <= priority
+		}
+		return handlers[i].priority < priority
+	})
+	updated := make([]exitHandler, 0, len(handlers)+1)
+	updated = append(updated, handlers[:i]...)
+	updated = append(updated, handler)
+	handlers = append(updated, handlers[i:]...)
+	return ExitHandle{id: handler.id}
 }
@@ -1,13 +1,18 @@
 package logrus
 
 import (
+	"bytes"
 	"io/ioutil"
 	"log"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
 	"time"
+
+	"github.com/stretchr/testify/assert"
 )
 
 func TestRegister(t *testing.T) {
@@ -18,6 +23,89 @@ func TestRegister(t *testing.T) {
 	}
 }
 
+// isolateExitHandlers removes the registered handlers for the duration of the test
+func isolateExitHandlers(t *testing.T) {
+	handlersMux.Lock()
+	saved := handlers
+	handlers = []exitHandler{}
+	handlersMux.Unlock()
+	t.Cleanup(func() {
+		handlersMux.Lock()
+		handlers = saved
+		handlersMux.Unlock()
+		SetExitTimeout(defaultExitTimeout)
+	})
+}
+
+func TestExitHandlersOrder(t *testing.T) {
+	isolateExitHandlers(t)
+	var order []string
+	record := func(name string) func() {
+		return func() { order = append(order, name) }
+	}
+
+	RegisterExitHandler(record("first"))
+	RegisterExitHandler(record("second"))
+	DeferExitHandler(record("deferred first"))
+	DeferExitHandler(record("deferred second"))
+	RegisterExitHandlerWithPriority(record("urgent"), 10)
+	RegisterExitHandlerWithPriority(record("last"), -1)
+	removed := RegisterExitHandler(record("removed"))
+	removed.Deregister()
+	removed.Deregister()
+
+	runHandlers(New(InfoLevel))
+	assert.Equal(t, []string{"urgent", "deferred second", "deferred first", "first", "second", "last"}, order)
+}
+
+func TestExitHandlerPanicIsLogged(t *testing.T) {
+	isolateExitHandlers(t)
+	var buffer bytes.Buffer
+	logger := New(InfoLevel)
+	logger.SetOutput(&buffer)
+	ran := false
+
+	RegisterExitHandler(func() { panic("broken handler") })
+	RegisterExitHandler(func() { ran = true })
+	runHandlers(logger)
+
+	assert.True(t, ran)
+	assert.Contains(t, buffer.String(), `msg="exit handler panicked"`)
+	assert.Contains(t, buffer.String(), `panic="broken handler"`)
+}
+
+func TestExitHandlersTimeout(t *testing.T) {
+	isolateExitHandlers(t)
+	var buffer bytes.Buffer
+	logger := New(InfoLevel)
+	logger.SetOutput(&buffer)
+	release := make(chan struct{})
+	defer close(release)
+
+	RegisterExitHandler(func() { <-release })
+	SetExitTimeout(50 * time.Millisecond)
+	start := time.Now()
+	runHandlers(logger)
+
+	assert.True(t, time.Since(start) < 5*time.Second)
+	assert.True(t, strings.Contains(buffer.String(), `msg="exit handlers timed out"`), buffer.String())
+}
+
+func TestRegisterExitHandlerConcurrently(t *testing.T) {
+	isolateExitHandlers(t)
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			RegisterExitHandler(func() {}).Deregister()
+			RegisterExitHandler(func() {})
+		}()
+	}
+	wg.Wait()
+	assert.Len(t, handlers, 50)
+}
+
 func TestHandler(t *testing.T) {
 	tempDir, err := ioutil.TempDir("", "test_handler")
 	if err != nil {
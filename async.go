@@ -0,0 +1,317 @@
+package logrus
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// defaultAsyncBufferSize is the number of entries queued when AsyncOptions.BufferSize is not set.
+const defaultAsyncBufferSize = 1024
+
+// exitFlushTimeout bounds how long Exit waits for the asynchronous loggers to drain.
+const exitFlushTimeout = 5 * time.Second
+
+// OverflowPolicy decides what happens to a new entry when the asynchronous buffer is full.
+type OverflowPolicy int
+
+const (
+	// OverflowBlock blocks the logging goroutine until the background writer makes room in the buffer.
+	OverflowBlock OverflowPolicy = iota
+	// OverflowDropNewest discards the entry being logged.
+	OverflowDropNewest
+	// OverflowDropOldest discards the oldest queued entry to make room for the new one.
+	OverflowDropOldest
+	// OverflowDropBelowLevel discards the entry being logged if it's less severe than
+	// AsyncOptions.DropLevel, otherwise it blocks like OverflowBlock.
+	OverflowDropBelowLevel
+)
+
+// AsyncOptions configures the asynchronous output of a Logger.
+type AsyncOptions struct {
+	// BufferSize is the maximum number of formatted entries waiting to be written to Out.
+	// The default is 1024.
+	BufferSize int
+
+	// Overflow is the policy applied when the buffer is full. The default is OverflowBlock.
+	Overflow OverflowPolicy
+
+	// DropLevel is only used by OverflowDropBelowLevel. Entries which are less severe than
+	// DropLevel get dropped when the buffer is full. For example with DropLevel set to
+	// WarnLevel, info and debug entries get dropped while warnings and errors wait for room.
+	DropLevel Level
+}
+
+var (
+	asyncLoggersMux sync.Mutex
+	asyncLoggers    = make(map[*Logger]struct{})
+)
+
+type asyncItem struct {
+	level Level
+	out   io.Writer
+	data  []byte
+}
+
+// asyncWriter is a bounded ring buffer of formatted entries, drained by a single background goroutine.
+type asyncWriter struct {
+	logger *Logger
+	opts   AsyncOptions
+
+	mux      sync.Mutex
+	notEmpty *sync.Cond
+	notFull  *sync.Cond
+	items    []asyncItem
+	head     int
+	count    int
+	writing  bool
+	closed   bool
+	waiters  []chan struct{}
+
+	dropped uint64
+	done    chan struct{}
+}
+
+func newAsyncWriter(logger *Logger, opts AsyncOptions) *asyncWriter {
+	if opts.BufferSize <= 0 {
+		opts.BufferSize = defaultAsyncBufferSize
+	}
+	w := &asyncWriter{
+		logger: logger,
+		opts:   opts,
+		items:  make([]asyncItem, opts.BufferSize),
+		done:   make(chan struct{}),
+	}
+	w.notEmpty = sync.NewCond(&w.mux)
+	w.notFull = sync.NewCond(&w.mux)
+	go w.run()
+	return w
+}
+
+func (w *asyncWriter) enqueue(level Level, out io.Writer, data []byte) {
+	w.mux.Lock()
+	for w.count == len(w.items) && !w.closed {
+		switch w.opts.Overflow {
+		case OverflowDropNewest:
+			atomic.AddUint64(&w.dropped, 1)
+			w.mux.Unlock()
+			return
+		case OverflowDropOldest:
+			w.items[w.head] = asyncItem{}
+			w.head = (w.head + 1) % len(w.items)
+			w.count--
+			atomic.AddUint64(&w.dropped, 1)
+		case OverflowDropBelowLevel:
+			if !w.opts.DropLevel.Enables(level) {
+				atomic.AddUint64(&w.dropped, 1)
+				w.mux.Unlock()
+				return
+			}
+			w.notFull.Wait()
+		default:
+			w.notFull.Wait()
+		}
+	}
+	if w.closed {
+		// The Logger has switched back to synchronous output in the meantime.
+		w.mux.Unlock()
+		<-w.done
+		w.logger.writeSync(out, data)
+		return
+	}
+	w.items[(w.head+w.count)%len(w.items)] = asyncItem{level: level, out: out, data: data}
+	w.count++
+	w.notEmpty.Signal()
+	w.mux.Unlock()
+}
+
+func (w *asyncWriter) run() {
+	defer close(w.done)
+	for {
+		w.mux.Lock()
+		for w.count == 0 && !w.closed {
+			w.notEmpty.Wait()
+		}
+		if w.count == 0 {
+			w.releaseWaiters()
+			w.mux.Unlock()
+			return
+		}
+		item := w.items[w.head]
+		w.items[w.head] = asyncItem{}
+		w.head = (w.head + 1) % len(w.items)
+		w.count--
+		w.writing = true
+		w.notFull.Signal()
+		w.mux.Unlock()
+
+		w.write(item.out, item.data)
+
+		w.mux.Lock()
+		w.writing = false
+		if w.count == 0 {
+			w.releaseWaiters()
+		}
+		w.mux.Unlock()
+	}
+}
+
+// write sends the entry to out, or to Out if out is nil, without holding the Logger's mutex
+// while writing, so a slow output does not block the hooks. It's safe because the background
+// goroutine is the only writer while the Logger is asynchronous.
+func (w *asyncWriter) write(out io.Writer, serialized []byte) {
+	if out == nil {
+		w.logger.mux.Lock()
+		out = w.logger.Out
+		w.logger.mux.Unlock()
+	}
+	if _, err := out.Write(serialized); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write to log, %v\n", err)
+	}
+}
+
+// releaseWaiters wakes up all the pending Flush calls. It must be called with w.mux held.
+func (w *asyncWriter) releaseWaiters() {
+	for _, ch := range w.waiters {
+		close(ch)
+	}
+	w.waiters = nil
+}
+
+func (w *asyncWriter) flush(ctx context.Context) error {
+	w.mux.Lock()
+	if w.count == 0 && !w.writing {
+		w.mux.Unlock()
+		return nil
+	}
+	ch := make(chan struct{})
+	w.waiters = append(w.waiters, ch)
+	w.mux.Unlock()
+
+	select {
+	case <-ch:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (w *asyncWriter) close() {
+	w.mux.Lock()
+	w.closed = true
+	w.notEmpty.Broadcast()
+	w.notFull.Broadcast()
+	w.mux.Unlock()
+	<-w.done
+}
+
+// SetAsync switches the Logger to asynchronous output. The entries are formatted by the
+// logging goroutine and queued in a bounded buffer which is written to Out by a background
+// goroutine, so a slow Out does not block the callers.
+//
+// Call Flush to wait for the queued entries to be written and Close to stop the background
+// writer and switch back to synchronous output. Exit flushes all the asynchronous loggers
+// before terminating the application, so fatal entries are not lost.
+func (logger *Logger) SetAsync(opts AsyncOptions) {
+	logger.Close()
+	w := newAsyncWriter(logger, opts)
+	logger.async.Store(w)
+
+	asyncLoggersMux.Lock()
+	asyncLoggers[logger] = struct{}{}
+	asyncLoggersMux.Unlock()
+}
+
+// Flush blocks until all the queued entries have been written to Out or the context is done.
+// It returns immediately if the Logger is not asynchronous.
+func (logger *Logger) Flush(ctx context.Context) error {
+	w := logger.asyncWriter()
+	if w == nil {
+		return nil
+	}
+	return w.flush(ctx)
+}
+
+// Close writes the queued entries to Out, stops the background writer and switches the Logger
+// back to synchronous output. It does nothing if the Logger is not asynchronous.
+func (logger *Logger) Close() error {
+	w := logger.asyncWriter()
+	if w == nil {
+		return nil
+	}
+	logger.async.Store((*asyncWriter)(nil))
+	w.close()
+
+	asyncLoggersMux.Lock()
+	delete(asyncLoggers, logger)
+	asyncLoggersMux.Unlock()
+	return nil
+}
+
+// Dropped returns the number of entries discarded by the overflow policy since the Logger
+// was switched to asynchronous output. It returns zero once the Logger has been closed.
+func (logger *Logger) Dropped() uint64 {
+	w := logger.asyncWriter()
+	if w == nil {
+		return 0
+	}
+	return atomic.LoadUint64(&w.dropped)
+}
+
+func (logger *Logger) asyncWriter() *asyncWriter {
+	w, _ := logger.async.Load().(*asyncWriter)
+	return w
+}
+
+// write sends the serialized entry to out (Out if out is nil), either directly or through the
+// asynchronous buffer.
+func (logger *Logger) write(level Level, out io.Writer, serialized []byte) {
+	if w := logger.asyncWriter(); w != nil {
+		w.enqueue(level, out, serialized)
+		return
+	}
+	logger.writeSync(out, serialized)
+}
+
+func (logger *Logger) writeSync(out io.Writer, serialized []byte) {
+	logger.mux.Lock()
+	defer logger.mux.Unlock()
+	if out == nil {
+		out = logger.Out
+	}
+	_, err := out.Write(serialized)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write to log, %v\n", err)
+	}
+}
+
+// flushWithin waits up to timeout for the queued entries to be written to Out.
+func (logger *Logger) flushWithin(timeout time.Duration) {
+	if logger.asyncWriter() == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if err := logger.Flush(ctx); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to flush the log, %v\n", err)
+	}
+}
+
+// flushAsyncLoggers waits for all the asynchronous loggers to drain their buffers.
+func flushAsyncLoggers(timeout time.Duration) {
+	asyncLoggersMux.Lock()
+	loggers := make([]*Logger, 0, len(asyncLoggers))
+	for logger := range asyncLoggers {
+		loggers = append(loggers, logger)
+	}
+	asyncLoggersMux.Unlock()
+
+	deadline := time.Now().Add(timeout)
+	for _, logger := range loggers {
+		logger.flushWithin(time.Until(deadline))
+	}
+}
@@ -0,0 +1,194 @@
+package logrus
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// gatedWriter blocks every Write until the gate is opened, so the tests can fill up the
+// asynchronous buffer deterministically.
+type gatedWriter struct {
+	gate    chan struct{}
+	started chan struct{}
+	once    sync.Once
+
+	mux sync.Mutex
+	buf bytes.Buffer
+}
+
+func newGatedWriter() *gatedWriter {
+	return &gatedWriter{
+		gate:    make(chan struct{}),
+		started: make(chan struct{}),
+	}
+}
+
+func (w *gatedWriter) Write(p []byte) (int, error) {
+	w.once.Do(func() { close(w.started) })
+	<-w.gate
+	w.mux.Lock()
+	defer w.mux.Unlock()
+	return w.buf.Write(p)
+}
+
+func (w *gatedWriter) open() {
+	close(w.gate)
+}
+
+func (w *gatedWriter) lines() []string {
+	w.mux.Lock()
+	defer w.mux.Unlock()
+	return strings.Split(strings.TrimSpace(w.buf.String()), "\n")
+}
+
+// newBlockedAsyncLogger returns an asynchronous logger whose background writer is stuck
+// writing the "first" entry, so the subsequent entries stay in the buffer.
+func newBlockedAsyncLogger(opts AsyncOptions) (*Logger, *gatedWriter) {
+	out := newGatedWriter()
+	logger := New(DebugLevel)
+	logger.SetOutput(out)
+	logger.SetFormatter(&TextFormatter{DisableColors: true, DisableTimestamp: true})
+	logger.SetAsync(opts)
+	logger.Info("first")
+	<-out.started
+	return logger, out
+}
+
+func TestAsyncWritesEntriesInOrder(t *testing.T) {
+	var buffer bytes.Buffer
+	logger := New(InfoLevel)
+	logger.SetOutput(&buffer)
+	logger.SetFormatter(&TextFormatter{DisableColors: true, DisableTimestamp: true})
+	logger.SetAsync(AsyncOptions{BufferSize: 4})
+	for i := 0; i < 10; i++ {
+		logger.Infof("message %d", i)
+	}
+	assert.Equal(t, uint64(0), logger.Dropped())
+	assert.NoError(t, logger.Close())
+
+	lines := strings.Split(strings.TrimSpace(buffer.String()), "\n")
+	assert.Len(t, lines, 10)
+	for i, line := range lines {
+		assert.Equal(t, fmt.Sprintf("level=info msg=\"message %d\"", i), line)
+	}
+}
+
+func TestAsyncOverflowDropNewest(t *testing.T) {
+	logger, out := newBlockedAsyncLogger(AsyncOptions{BufferSize: 2, Overflow: OverflowDropNewest})
+	logger.Info("second")
+	logger.Info("third")
+	logger.Info("fourth")
+	assert.Equal(t, uint64(1), logger.Dropped())
+
+	out.open()
+	logger.Close()
+	assert.Equal(t, []string{"level=info msg=first", "level=info msg=second", "level=info msg=third"}, out.lines())
+}
+
+func TestAsyncOverflowDropOldest(t *testing.T) {
+	logger, out := newBlockedAsyncLogger(AsyncOptions{BufferSize: 2, Overflow: OverflowDropOldest})
+	logger.Info("second")
+	logger.Info("third")
+	logger.Info("fourth")
+	assert.Equal(t, uint64(1), logger.Dropped())
+
+	out.open()
+	logger.Close()
+	assert.Equal(t, []string{"level=info msg=first", "level=info msg=third", "level=info msg=fourth"}, out.lines())
+}
+
+func TestAsyncOverflowDropBelowLevel(t *testing.T) {
+	logger, out := newBlockedAsyncLogger(AsyncOptions{BufferSize: 1, Overflow: OverflowDropBelowLevel, DropLevel: WarnLevel})
+	logger.Info("second")
+	logger.Info("dropped")
+	logger.Debug("dropped")
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		logger.Error("blocked")
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("expected the error entry to wait for room in the buffer")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	out.open()
+	<-done
+	assert.Equal(t, uint64(2), logger.Dropped())
+	logger.Close()
+	assert.Equal(t, []string{"level=info msg=first", "level=info msg=second", "level=error msg=blocked"}, out.lines())
+}
+
+func TestAsyncFlushHonoursContext(t *testing.T) {
+	logger, out := newBlockedAsyncLogger(AsyncOptions{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, logger.Flush(ctx))
+
+	out.open()
+	assert.NoError(t, logger.Flush(context.Background()))
+	assert.Equal(t, []string{"level=info msg=first"}, out.lines())
+	logger.Close()
+}
+
+func TestAsyncCloseSwitchesBackToSynchronousOutput(t *testing.T) {
+	var buffer bytes.Buffer
+	logger := New(InfoLevel)
+	logger.SetOutput(&buffer)
+	logger.SetAsync(AsyncOptions{})
+	assert.NoError(t, logger.Close())
+	assert.NoError(t, logger.Close())
+
+	logger.Info("sync")
+	assert.Contains(t, buffer.String(), "msg=sync")
+	assert.NoError(t, logger.Flush(context.Background()))
+}
+
+func TestFlushAsyncLoggers(t *testing.T) {
+	logger, out := newBlockedAsyncLogger(AsyncOptions{})
+	logger.Info("second")
+	out.open()
+
+	flushAsyncLoggers(time.Second)
+	assert.Equal(t, []string{"level=info msg=first", "level=info msg=second"}, out.lines())
+	logger.Close()
+
+	asyncLoggersMux.Lock()
+	_, registered := asyncLoggers[logger]
+	asyncLoggersMux.Unlock()
+	assert.False(t, registered)
+}
+
+func TestAsyncLoggingRace(t *testing.T) {
+	var buffer bytes.Buffer
+	logger := New(InfoLevel)
+	logger.SetOutput(&buffer)
+	logger.SetAsync(AsyncOptions{BufferSize: 8, Overflow: OverflowDropOldest})
+
+	var wg sync.WaitGroup
+	wg.Add(100)
+	for i := 0; i < 100; i++ {
+		go func() {
+			defer wg.Done()
+			logger.Info("info")
+		}()
+	}
+	wg.Wait()
+	assert.NoError(t, logger.Flush(context.Background()))
+	dropped := logger.Dropped()
+	logger.Close()
+
+	lines := strings.Count(buffer.String(), "\n")
+	assert.Equal(t, 100, lines+int(dropped))
+}
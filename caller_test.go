@@ -0,0 +1,211 @@
+package logrus_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/xitonix/logrus"
+)
+
+// The caller tests live outside of the package, since the frames of the package are skipped
+
+const (
+	funcKey = "func"
+	fileKey = "file"
+)
+
+// nextLine returns the location of the line following its caller, formatted like the `file` field
+func nextLine() string {
+	_, file, line, _ := runtime.Caller(1)
+	return fmt.Sprintf("%s:%d", file, line+1)
+}
+
+func newCallerLogger(buffer *bytes.Buffer) *logrus.Logger {
+	logger := logrus.New(logrus.InfoLevel)
+	logger.SetOutput(buffer)
+	logger.SetFormatter(new(logrus.JSONFormatter))
+	logger.SetReportCaller(true)
+	return logger
+}
+
+func decodeFields(t *testing.T, b []byte) logrus.Fields {
+	var fields logrus.Fields
+	require.NoError(t, json.Unmarshal(b, &fields))
+	return fields
+}
+
+// logWarning is a helper wrapping the logger, reported as the caller
+func logWarning(logger *logrus.Logger, msg string) string {
+	expected := nextLine()
+	logger.AsWarning().Write(msg)
+	return expected
+}
+
+func TestReportCallerIsDisabledByDefault(t *testing.T) {
+	var buffer bytes.Buffer
+	logger := newCallerLogger(&buffer)
+	logger.SetReportCaller(false)
+	logger.Info("test")
+
+	fields := decodeFields(t, buffer.Bytes())
+	assert.Nil(t, fields[funcKey])
+	assert.Nil(t, fields[fileKey])
+}
+
+func TestReportCallerWhenConfigured(t *testing.T) {
+	testCases := []struct {
+		title string
+		log   func(*logrus.Logger) string
+	}{
+		{
+			title: "logger_method",
+			log: func(log *logrus.Logger) string {
+				expected := nextLine()
+				log.Infof("test %d", 1)
+				return expected
+			},
+		},
+		{
+			title: "entry_write",
+			log: func(log *logrus.Logger) string {
+				expected := nextLine()
+				log.AsError().WithField("key", "value").Write("test")
+				return expected
+			},
+		},
+		{
+			title: "entry_writeln",
+			log: func(log *logrus.Logger) string {
+				entry := logrus.NewEntry(log)
+				expected := nextLine()
+				entry.Writeln("test")
+				return expected
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			var buffer bytes.Buffer
+			expected := tc.log(newCallerLogger(&buffer))
+
+			fields := decodeFields(t, buffer.Bytes())
+			assert.Equal(t, expected, fields[fileKey])
+			assert.True(t, strings.HasPrefix(fields[funcKey].(string), "github.com/xitonix/logrus_test.TestReportCallerWhenConfigured"), fields[funcKey])
+		})
+	}
+}
+
+func TestReportCallerOfAHelper(t *testing.T) {
+	var buffer bytes.Buffer
+	expected := logWarning(newCallerLogger(&buffer), "test")
+
+	fields := decodeFields(t, buffer.Bytes())
+	assert.Equal(t, expected, fields[fileKey])
+	assert.Equal(t, "github.com/xitonix/logrus_test.logWarning", fields[funcKey])
+}
+
+func TestReportCallerOfTheExportedFunctions(t *testing.T) {
+	var buffer bytes.Buffer
+	std := logrus.StandardLogger()
+	oldOut := std.Out
+	defer func() {
+		logrus.SetOutput(oldOut)
+		logrus.SetFormatter(&logrus.TextFormatter{DisableSorting: true, DisableColors: true})
+		logrus.SetReportCaller(false)
+	}()
+	logrus.SetOutput(&buffer)
+	logrus.SetFormatter(new(logrus.JSONFormatter))
+	logrus.SetReportCaller(true)
+
+	expected := nextLine()
+	logrus.Warning("test")
+
+	fields := decodeFields(t, buffer.Bytes())
+	assert.Equal(t, expected, fields[fileKey])
+	assert.Equal(t, "github.com/xitonix/logrus_test.TestReportCallerOfTheExportedFunctions", fields[funcKey])
+}
+
+func TestReportCallerOfTheWriter(t *testing.T) {
+	var buffer bytes.Buffer
+	log := newCallerLogger(&buffer)
+
+	expected := nextLine()
+	w := log.WriterLevel(logrus.WarnLevel)
+	defer w.Close()
+	w.Write([]byte("hello\n"))
+
+	fields := decodeFields(t, buffer.Bytes())
+	assert.Equal(t, expected, fields[fileKey])
+	assert.Equal(t, "github.com/xitonix/logrus_test.TestReportCallerOfTheWriter", fields[funcKey])
+}
+
+func TestReportCallerOfTheStandardLog(t *testing.T) {
+	var buffer bytes.Buffer
+	logger := newCallerLogger(&buffer)
+
+	log.SetFlags(0)
+	defer log.SetFlags(log.LstdFlags)
+	restore := logger.RedirectStdLog(logrus.WarnLevel)
+	defer restore()
+
+	expected := nextLine()
+	log.Print("hello")
+
+	fields := decodeFields(t, buffer.Bytes())
+	assert.Equal(t, "hello", fields["msg"])
+	assert.Equal(t, expected, fields[fileKey])
+	assert.Equal(t, "github.com/xitonix/logrus_test.TestReportCallerOfTheStandardLog", fields[funcKey])
+}
+
+func TestReportCallerWithPrettyfier(t *testing.T) {
+	prettyfier := func(f *runtime.Frame) (string, string) {
+		return "", filepath.Base(f.File)
+	}
+
+	var buffer bytes.Buffer
+	log := newCallerLogger(&buffer)
+	log.SetFormatter(&logrus.JSONFormatter{CallerPrettyfier: prettyfier})
+	log.Info("test")
+
+	fields := decodeFields(t, buffer.Bytes())
+	assert.Nil(t, fields[funcKey])
+	assert.Equal(t, "caller_test.go", fields[fileKey])
+
+	buffer.Reset()
+	log.SetFormatter(&logrus.TextFormatter{DisableColors: true, DisableTimestamp: true, CallerPrettyfier: prettyfier})
+	log.Info("test")
+	assert.Equal(t, "level=info msg=test file=caller_test.go\n", buffer.String())
+}
+
+func TestReportCallerPrefixesClashingFields(t *testing.T) {
+	var buffer bytes.Buffer
+	log := newCallerLogger(&buffer)
+	log.WithField(fileKey, "user value").Write("test")
+
+	fields := decodeFields(t, buffer.Bytes())
+	assert.Equal(t, "user value", fields["fields.file"])
+	assert.NotEqual(t, "user value", fields[fileKey])
+}
+
+func TestReportCallerColoredOutput(t *testing.T) {
+	tf := &logrus.TextFormatter{ForceColors: true, DisableTimestamp: true}
+	entry := &logrus.Entry{
+		Message: "test",
+		Level:   logrus.InfoLevel,
+		Caller:  &runtime.Frame{Function: "pkg.Func", File: "file.go", Line: 7},
+	}
+
+	b, err := tf.Format(entry)
+	assert.NoError(t, err)
+	assert.Contains(t, string(b), " pkg.Func() file.go:7 test")
+}
@@ -0,0 +1,169 @@
+package logrus
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+)
+
+// componentLevels maps the component names to their level. It's never modified once stored in
+// the Logger: the changes are made on a copy.
+type componentLevels map[string]Level
+
+// Named creates an entry for the component with the name, adding the name as the `logger`
+// field. The level of the component's entries is the one set for the component with
+// SetComponentLevel, or for the closest parent component, or the Logger's level if none is
+// set. See Entry.Named.
+func (logger *Logger) Named(name string) *Entry {
+	entry := logger.newEntry()
+	defer logger.releaseEntry(entry)
+	return entry.Named(name)
+}
+
+// Named creates an entry for the child component with the name. The names of the child
+// components are made of the names of their parents, separated by dots: for example,
+// logger.Named("db").Named("pool") is the "db.pool" component, whose level is the level set
+// for "db.pool", or for "db" if none is set for "db.pool".
+//
+// Like for the Logger, the entries of the disabled levels are not cloned, so the fields added
+// to a disabled component's entries are not allocated:
+//
+//	pool := logger.Named("db").Named("pool")
+//	pool.AsDebug().WithField("conn", id).Write("connection acquired")
+func (entry *Entry) Named(name string) *Entry {
+	if entry.name != "" {
+		name = entry.name + "." + name
+	}
+	data := make(Fields, len(entry.Data)+1)
+	for k, v := range entry.Data {
+		data[k] = v
+	}
+	data[loggerKey] = name
+	clone := entry.clone(entry.Level, data)
+	clone.name = name
+	return clone
+}
+
+// Name returns the name of the entry's component, or an empty string if the entry was not
+// created with Named.
+func (entry *Entry) Name() string {
+	return entry.name
+}
+
+// isEnabled returns true if the entry's level is written by the Logger, the level of the entry's
+// component standing in for the Logger's level
+func (entry *Entry) isEnabled() bool {
+	if entry.name != "" {
+		if level, ok := entry.Logger.componentLevel(entry.name); ok {
+			return level.Enables(entry.Level) || Level(atomic.LoadUint32(&entry.Logger.sinkLevel)).Enables(entry.Level)
+		}
+	}
+	return entry.Logger.IsLevelEnabled(entry.Level)
+}
+
+// outputLevel returns the level of the entries written to Out for the entry's component
+func (logger *Logger) outputLevel(entry *Entry) Level {
+	if entry.name != "" {
+		if level, ok := logger.componentLevel(entry.name); ok {
+			return level
+		}
+	}
+	return logger.Level()
+}
+
+// componentLevel returns the level set for the component or its closest parent
+func (logger *Logger) componentLevel(name string) (Level, bool) {
+	levels, _ := logger.components.Load().(componentLevels)
+	if len(levels) == 0 {
+		return 0, false
+	}
+	for {
+		if level, ok := levels[name]; ok {
+			return level, true
+		}
+		i := strings.LastIndexByte(name, '.')
+		if i < 0 {
+			return 0, false
+		}
+		name = name[:i]
+	}
+}
+
+// SetComponentLevel sets the level of the component and of its children which have no level of
+// their own. It can be called at any time, the entries already created with Named included.
+func (logger *Logger) SetComponentLevel(name string, level Level) {
+	logger.updateComponentLevels(func(levels componentLevels) {
+		levels[name] = level
+	})
+}
+
+// ResetComponentLevel removes the level of the component, which inherits the level of its
+// parent again.
+func (logger *Logger) ResetComponentLevel(name string) {
+	logger.updateComponentLevels(func(levels componentLevels) {
+		delete(levels, name)
+	})
+}
+
+// SetComponentLevels replaces the levels of all the components. The empty name sets the
+// Logger's level. See ParseComponentLevels to read the levels from a configuration string.
+func (logger *Logger) SetComponentLevels(levels map[string]Level) {
+	if level, ok := levels[""]; ok {
+		logger.SetLevel(level)
+	}
+	logger.updateComponentLevels(func(current componentLevels) {
+		for name := range current {
+			delete(current, name)
+		}
+		for name, level := range levels {
+			if name != "" {
+				current[name] = level
+			}
+		}
+	})
+}
+
+// ComponentLevels returns a copy of the levels set for the components.
+func (logger *Logger) ComponentLevels() map[string]Level {
+	levels, _ := logger.components.Load().(componentLevels)
+	copied := make(map[string]Level, len(levels))
+	for name, level := range levels {
+		copied[name] = level
+	}
+	return copied
+}
+
+func (logger *Logger) updateComponentLevels(update func(componentLevels)) {
+	logger.componentsMux.Lock()
+	defer logger.componentsMux.Unlock()
+	current, _ := logger.components.Load().(componentLevels)
+	levels := make(componentLevels, len(current)+1)
+	for name, level := range current {
+		levels[name] = level
+	}
+	update(levels)
+	logger.components.Store(levels)
+}
+
+// ParseComponentLevels parses a comma separated list of component levels, like
+// "db=debug,db.pool=warn,info". A level without a component name is the Logger's level, and
+// is returned with the empty name.
+func ParseComponentLevels(spec string) (map[string]Level, error) {
+	levels := make(map[string]Level)
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, value := "", part
+		if i := strings.IndexByte(part, '='); i >= 0 {
+			name, value = strings.TrimSpace(part[:i]), strings.TrimSpace(part[i+1:])
+		}
+		level, err := ParseLevel(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid level of the %q component: %v", name, err)
+		}
+		levels[name] = level
+	}
+	return levels, nil
+}
@@ -0,0 +1,144 @@
+package logrus
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNamedAddsTheLoggerField(t *testing.T) {
+	LogAndAssertJSON(t, func(log *Logger) {
+		log.Named("db").Named("pool").AsInfo().WithField("conn", 1).Write("acquired")
+	}, func(fields Fields) {
+		assert.Equal(t, "db.pool", fields["logger"])
+		assert.Equal(t, "acquired", fields["msg"])
+		assert.Equal(t, float64(1), fields["conn"])
+	})
+}
+
+func TestNamedKeepsTheParentFields(t *testing.T) {
+	logger := New(InfoLevel)
+	db := logger.WithField("service", "api").Named("db")
+	pool := db.Named("pool")
+
+	assert.Equal(t, "db", db.Name())
+	assert.Equal(t, "db.pool", pool.Name())
+	assert.Equal(t, "db.pool", pool.AsWarning().Name())
+	assert.Equal(t, Fields{"service": "api", "logger": "db.pool"}, pool.Data)
+	assert.Equal(t, Fields{"service": "api", "logger": "db"}, db.Data)
+}
+
+func TestComponentLevels(t *testing.T) {
+	var buffer bytes.Buffer
+	logger := New(InfoLevel)
+	logger.SetOutput(&buffer)
+	logger.SetFormatter(new(JSONFormatter))
+	logger.SetComponentLevels(map[string]Level{"db": DebugLevel, "db.pool": WarnLevel})
+
+	db := logger.Named("db")
+	pool := db.Named("pool")
+	conn := pool.Named("conn")
+	api := logger.Named("http")
+
+	db.AsDebug().Write("db debug")
+	pool.AsInfo().Write("pool info")
+	pool.AsWarning().Write("pool warning")
+	conn.AsInfo().Write("conn info")
+	api.AsDebug().Write("http debug")
+	api.AsInfo().Write("http info")
+	logger.Debug("root debug")
+
+	var messages []string
+	for _, line := range strings.Split(strings.TrimSpace(buffer.String()), "\n") {
+		var fields Fields
+		require.NoError(t, json.Unmarshal([]byte(line), &fields))
+		messages = append(messages, fields["msg"].(string))
+	}
+	assert.Equal(t, []string{"db debug", "pool warning", "http info"}, messages)
+}
+
+func TestComponentLevelChangesAtRuntime(t *testing.T) {
+	var buffer bytes.Buffer
+	logger := New(InfoLevel)
+	logger.SetOutput(&buffer)
+	pool := logger.Named("db").Named("pool")
+
+	pool.AsDebug().Write("before")
+	assert.Empty(t, buffer.String())
+
+	logger.SetComponentLevel("db", DebugLevel)
+	pool.AsDebug().Write("inherited")
+	assert.Contains(t, buffer.String(), "inherited")
+
+	logger.SetComponentLevel("db.pool", ErrorLevel)
+	buffer.Reset()
+	pool.AsWarning().Write("overridden")
+	assert.Empty(t, buffer.String())
+
+	logger.ResetComponentLevel("db.pool")
+	pool.AsDebug().Write("reset")
+	assert.Contains(t, buffer.String(), "reset")
+	assert.Equal(t, map[string]Level{"db": DebugLevel}, logger.ComponentLevels())
+}
+
+func TestComponentLevelWithSinks(t *testing.T) {
+	var primary, debug bytes.Buffer
+	logger := New(InfoLevel)
+	logger.SetOutput(&primary)
+	logger.AddSink(Sink{Out: &debug, Level: DebugLevel})
+	logger.SetComponentLevel("db", WarnLevel)
+
+	logger.Named("db").AsInfo().Write("db info")
+	assert.Empty(t, primary.String())
+	assert.Contains(t, debug.String(), "db info")
+}
+
+func TestDisabledComponentDoesNotAllocateFields(t *testing.T) {
+	logger := New(DebugLevel)
+	logger.SetOutput(&bytes.Buffer{})
+	logger.SetComponentLevel("db", WarnLevel)
+	debug := logger.Named("db").Named("pool").AsDebug()
+
+	allocs := testing.AllocsPerRun(100, func() {
+		debug.WithField("conn", "primary").WithFields(Fields{}).With(String("user", "walrus")).Write("acquired")
+	})
+	assert.Equal(t, float64(0), allocs)
+}
+
+func TestParseComponentLevels(t *testing.T) {
+	levels, err := ParseComponentLevels("db=debug, db.pool=warn,info,")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]Level{"": InfoLevel, "db": DebugLevel, "db.pool": WarnLevel}, levels)
+
+	_, err = ParseComponentLevels("db=loud")
+	assert.Error(t, err)
+
+	logger := New(ErrorLevel)
+	logger.SetComponentLevel("http", DebugLevel)
+	logger.SetComponentLevels(levels)
+	assert.Equal(t, InfoLevel, logger.Level())
+	assert.Equal(t, map[string]Level{"db": DebugLevel, "db.pool": WarnLevel}, logger.ComponentLevels())
+}
+
+func TestLevelHandlerComponents(t *testing.T) {
+	logger := New(InfoLevel)
+
+	code, response := serveLevel(t, logger, http.MethodPut, "/level", "application/json", `{"logger":"db.pool","level":"debug"}`)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "info", response["level"])
+	assert.Equal(t, map[string]interface{}{"db.pool": "debug"}, response["components"])
+	assert.Equal(t, map[string]Level{"db.pool": DebugLevel}, logger.ComponentLevels())
+
+	code, _ = serveLevel(t, logger, http.MethodPut, "/level?logger=db.pool&level=debug&duration=1m", "", "")
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	code, response = serveLevel(t, logger, http.MethodPost, "/level?logger=db.pool", "", "")
+	assert.Equal(t, http.StatusOK, code)
+	assert.NotContains(t, response, "components")
+	assert.Empty(t, logger.ComponentLevels())
+}
@@ -0,0 +1,112 @@
+package logrus
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type contextKey string
+
+const requestIDKey contextKey = "request_id"
+
+func requestIDExtractor(ctx context.Context) Fields {
+	id, ok := ctx.Value(requestIDKey).(string)
+	if !ok {
+		return nil
+	}
+	return Fields{"request_id": id}
+}
+
+func TestWithContextKeepsTheContext(t *testing.T) {
+	logger := New(DebugLevel)
+	ctx := context.WithValue(context.Background(), requestIDKey, "42")
+
+	entry := logger.WithContext(ctx)
+	assert.Equal(t, ctx, entry.Context)
+	assert.Equal(t, ctx, entry.AsWarning().Context)
+	assert.Equal(t, ctx, entry.WithField("key", "value").Context)
+	assert.Equal(t, ctx, entry.WithFields(Fields{"key": "value"}).Context)
+	assert.Equal(t, ctx, entry.AsError().WithError(nil).Context)
+}
+
+func TestWithContextOnDisabledLevelDoesNotClone(t *testing.T) {
+	logger := New(InfoLevel)
+	entry := logger.AsDebug()
+	assert.True(t, entry == entry.WithContext(context.Background()))
+}
+
+func TestContextExtractorFieldsAreLogged(t *testing.T) {
+	ctx := context.WithValue(context.Background(), requestIDKey, "42")
+
+	LogAndAssertJSON(t, func(log *Logger) {
+		log.AddContextExtractor(requestIDExtractor)
+		log.WithContext(ctx).WithField("key", "value").Write("test")
+	}, func(fields Fields) {
+		assert.Equal(t, "42", fields["request_id"])
+		assert.Equal(t, "value", fields["key"])
+	})
+
+	LogAndAssertText(t, func(log *Logger) {
+		log.AddContextExtractor(requestIDExtractor)
+		log.WithContext(ctx).AsWarning().Write("test")
+	}, func(fields map[string]string) {
+		assert.Equal(t, "42", strings.TrimSpace(fields["request_id"]))
+	})
+}
+
+func TestContextExtractorDoesNotModifyTheEntry(t *testing.T) {
+	var buffer bytes.Buffer
+	logger := New(InfoLevel)
+	logger.SetOutput(&buffer)
+	logger.SetFormatter(new(JSONFormatter))
+	logger.AddContextExtractor(requestIDExtractor)
+
+	entry := logger.WithField("key", "value").WithContext(context.WithValue(context.Background(), requestIDKey, "42"))
+	entry.Write("test")
+
+	assert.Equal(t, Fields{"key": "value"}, entry.Data)
+}
+
+func TestExplicitFieldsTakePrecedenceOverContextFields(t *testing.T) {
+	ctx := context.WithValue(context.Background(), requestIDKey, "42")
+
+	LogAndAssertJSON(t, func(log *Logger) {
+		log.AddContextExtractor(requestIDExtractor)
+		log.WithContext(ctx).WithField("request_id", "explicit").Write("test")
+	}, func(fields Fields) {
+		assert.Equal(t, "explicit", fields["request_id"])
+	})
+}
+
+func TestContextExtractorsRunInOrder(t *testing.T) {
+	ctx := context.WithValue(context.Background(), requestIDKey, "42")
+
+	LogAndAssertJSON(t, func(log *Logger) {
+		log.AddContextExtractor(requestIDExtractor)
+		log.AddContextExtractor(func(ctx context.Context) Fields {
+			return Fields{"request_id": "overridden", "tenant": "acme"}
+		})
+		log.WithContext(ctx).Write("test")
+	}, func(fields Fields) {
+		assert.Equal(t, "overridden", fields["request_id"])
+		assert.Equal(t, "acme", fields["tenant"])
+	})
+}
+
+func TestContextWithoutExtractors(t *testing.T) {
+	var buffer bytes.Buffer
+	var fields Fields
+
+	logger := New(InfoLevel)
+	logger.SetOutput(&buffer)
+	logger.SetFormatter(new(JSONFormatter))
+	logger.WithContext(context.WithValue(context.Background(), requestIDKey, "42")).Write("test")
+
+	assert.NoError(t, json.Unmarshal(buffer.Bytes(), &fields))
+	assert.Len(t, fields, 3)
+}
@@ -0,0 +1,169 @@
+package logrus
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// firstCustomLevel is the value of the first registered level. The values in between are left
+// for the predefined levels.
+const firstCustomLevel Level = 100
+
+// severityShift spaces out the ranks of the predefined levels, so the registered levels are
+// ranked between them
+const severityShift = 16
+
+// customLevel is a level registered with RegisterLevel
+type customLevel struct {
+	name     string
+	severity uint64
+}
+
+// customLevelSet is the immutable set of the registered levels. It's replaced on every
+// registration, so it's read without locking.
+type customLevelSet struct {
+	byLevel map[Level]customLevel
+	byName  map[string]Level
+	// levels are the predefined and the registered levels, the most severe first
+	levels []Level
+}
+
+var (
+	customLevelsMu sync.Mutex
+	// customLevels holds the *customLevelSet. It's initialized with the package variables, since
+	// the levels may be registered by the package variables of the tests.
+	customLevels = newCustomLevels()
+)
+
+func newCustomLevels() *atomic.Value {
+	v := new(atomic.Value)
+	v.Store(&customLevelSet{
+		byLevel: make(map[Level]customLevel),
+		byName:  make(map[string]Level),
+		levels:  append([]Level(nil), AllLevels...),
+	})
+	return v
+}
+
+func loadCustomLevels() *customLevelSet {
+	return customLevels.Load().(*customLevelSet)
+}
+
+// RegisterLevel registers an additional level with its name, which is returned by Level.String
+// and accepted by ParseLevel, and returns its value. The level is less severe than the
+// predefined level after, and more severe than the next predefined one. The levels registered
+// after the same predefined level are ordered by registration, the first being the more severe:
+//
+//	var NoticeLevel = logrus.MustRegisterLevel("notice", logrus.WarnLevel) // between warning and info
+//
+//	logger.AsLevel(NoticeLevel).Write("configuration reloaded")
+//
+// The registered levels get their own values, from 100 up in the order they are registered, so
+// the values of the predefined levels never change. They are meant to be registered from the
+// package variables or the init functions, so that the values are the same on every run. The
+// entries at the registered levels never exit or panic, like the predefined levels between
+// Error and Trace.
+//
+// The registered levels are not added to AllLevels, which only holds the predefined ones, but
+// they are returned by Levels.
+func RegisterLevel(name string, after Level) (Level, error) {
+	name = strings.ToLower(name)
+	if name == "" {
+		return 0, fmt.Errorf("the name of the level is empty")
+	}
+	if after > TraceLevel {
+		return 0, fmt.Errorf("the %q level must be registered after a predefined level, not %d", name, after)
+	}
+
+	customLevelsMu.Lock()
+	defer customLevelsMu.Unlock()
+	if existing, err := ParseLevel(name); err == nil {
+		return 0, fmt.Errorf("the %q level is already registered as %d", name, existing)
+	}
+
+	old := loadCustomLevels()
+	level := firstCustomLevel + Level(len(old.byLevel))
+	severity := after.severity()
+	for _, c := range old.byLevel {
+		if c.severity > severity && c.severity < (after+1).severity() {
+			severity = c.severity
+		}
+	}
+	severity++
+	if severity == (after + 1).severity() {
+		return 0, fmt.Errorf("too many levels registered after %s", after)
+	}
+
+	set := &customLevelSet{
+		byLevel: make(map[Level]customLevel, len(old.byLevel)+1),
+		byName:  make(map[string]Level, len(old.byName)+1),
+		levels:  append(append(make([]Level, 0, len(old.levels)+1), old.levels...), level),
+	}
+	for l, c := range old.byLevel {
+		set.byLevel[l] = c
+	}
+	for n, l := range old.byName {
+		set.byName[n] = l
+	}
+	set.byLevel[level] = customLevel{name: name, severity: severity}
+	set.byName[name] = level
+	sort.Slice(set.levels, func(i, j int) bool {
+		return set.severityOf(set.levels[i]) < set.severityOf(set.levels[j])
+	})
+	customLevels.Store(set)
+	return level, nil
+}
+
+// MustRegisterLevel is like RegisterLevel but panics if the level can't be registered. It's
+// meant to initialize the package variables holding the levels.
+func MustRegisterLevel(name string, after Level) Level {
+	level, err := RegisterLevel(name, after)
+	if err != nil {
+		panic(err)
+	}
+	return level
+}
+
+// Levels returns the predefined levels and the levels registered with RegisterLevel, the most
+// severe first. The returned slice must not be modified.
+func Levels() []Level {
+	return loadCustomLevels().levels
+}
+
+// Enables returns true if a Logger at the level writes the entries at the other level, that is
+// if the other level is as severe as the level or more. The registered levels are ranked
+// between the predefined level they are registered after and the next one.
+func (level Level) Enables(other Level) bool {
+	return other.severity() <= level.severity()
+}
+
+// severity returns the rank of the level, the lower the more severe
+func (level Level) severity() uint64 {
+	if level <= TraceLevel {
+		return uint64(level) << severityShift
+	}
+	return loadCustomLevels().severityOf(level)
+}
+
+func (set *customLevelSet) severityOf(level Level) uint64 {
+	if c, ok := set.byLevel[level]; ok {
+		return c.severity
+	}
+	// The unknown levels are less severe than the predefined ones, in the order of their values
+	return uint64(level) << severityShift
+}
+
+// customLevelName returns the name of a registered level
+func customLevelName(level Level) (string, bool) {
+	c, ok := loadCustomLevels().byLevel[level]
+	return c.name, ok
+}
+
+// customLevelByName returns the registered level with the name
+func customLevelByName(name string) (Level, bool) {
+	level, ok := loadCustomLevels().byName[name]
+	return level, ok
+}
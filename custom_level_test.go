@@ -0,0 +1,79 @@
+package logrus
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// noticeLevel is registered once for all the tests, since the registrations can't be undone
+var noticeLevel = MustRegisterLevel("Notice", WarnLevel)
+
+func TestPredefinedLevelValues(t *testing.T) {
+	assert.Equal(t, []Level{0, 1, 2, 3, 4, 5, 6}, []Level{PanicLevel, FatalLevel, ErrorLevel, WarnLevel, InfoLevel, DebugLevel, TraceLevel})
+	assert.Equal(t, []Level{PanicLevel, FatalLevel, ErrorLevel, WarnLevel, InfoLevel, DebugLevel, TraceLevel}, AllLevels)
+}
+
+func TestRegisteredLevelName(t *testing.T) {
+	assert.Equal(t, "notice", noticeLevel.String())
+	assert.True(t, noticeLevel > TraceLevel)
+
+	level, err := ParseLevel("NOTICE")
+	require.NoError(t, err)
+	assert.Equal(t, noticeLevel, level)
+	assert.NotContains(t, AllLevels, noticeLevel)
+	assert.Equal(t, []Level{PanicLevel, FatalLevel, ErrorLevel, WarnLevel, noticeLevel, InfoLevel, DebugLevel, TraceLevel}, Levels())
+}
+
+func TestRegisteredLevelOrder(t *testing.T) {
+	assert.True(t, InfoLevel.Enables(noticeLevel))
+	assert.True(t, noticeLevel.Enables(WarnLevel))
+	assert.True(t, noticeLevel.Enables(noticeLevel))
+	assert.False(t, noticeLevel.Enables(InfoLevel))
+	assert.False(t, WarnLevel.Enables(noticeLevel))
+	assert.True(t, TraceLevel.Enables(DebugLevel))
+	assert.False(t, DebugLevel.Enables(TraceLevel))
+}
+
+func TestRegisterLevelConflicts(t *testing.T) {
+	_, err := RegisterLevel("notice", InfoLevel)
+	assert.Error(t, err)
+	_, err = RegisterLevel("debug", InfoLevel)
+	assert.Error(t, err)
+	_, err = RegisterLevel("", InfoLevel)
+	assert.Error(t, err)
+	_, err = RegisterLevel("audit", noticeLevel)
+	assert.Error(t, err)
+}
+
+func TestRegisteredLevelGating(t *testing.T) {
+	LogAndAssertJSON(t, func(log *Logger) {
+		log.AsLevel(noticeLevel).WithField("key", "value").Write("reloaded")
+	}, func(fields Fields) {
+		assert.Equal(t, "notice", fields[levelKey])
+		assert.Equal(t, "value", fields["key"])
+	})
+
+	var buffer bytes.Buffer
+	logger := New(WarnLevel)
+	logger.Out = &buffer
+	logger.AsLevel(noticeLevel).Write("reloaded")
+	assert.Empty(t, buffer.String())
+}
+
+func TestRegisteredLevelColor(t *testing.T) {
+	var buffer bytes.Buffer
+	logger := New(InfoLevel)
+	logger.Out = &buffer
+	logger.SetFormatter(&TextFormatter{ForceColors: true, DisableTimestamp: true})
+
+	logger.AsLevel(noticeLevel).Write("reloaded")
+	assert.Contains(t, buffer.String(), "\x1b[36mNOTI\x1b[0m")
+
+	buffer.Reset()
+	logger.SetLevel(TraceLevel)
+	logger.Trace("dump")
+	assert.Contains(t, buffer.String(), "\x1b[32mTRAC\x1b[0m")
+}
@@ -1,11 +1,24 @@
 package logrus
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"runtime"
+	"strings"
+	"sync"
 	"time"
 )
 
+const maximumCallerDepth = 25
+
+var (
+	// logrusPackage is the qualified package name of this package, used to skip
+	// the frames inside logrus when looking for the caller of a log method
+	logrusPackage     string
+	logrusPackageOnce sync.Once
+)
+
 // Entry an entry is the final or intermediate Logrus logging entry. It contains all
 // the fields passed with WithField{,s}. It's finally logged when Write method is called. These objects can be reused and
 // passed around as much as you wish to avoid field duplication.
@@ -15,25 +28,48 @@ type Entry struct {
 	// Data contains all the fields set by the user.
 	Data Fields
 
+	// Typed contains the typed fields added with With, in the order they were added.
+	// A typed field takes precedence over the field of Data with the same key.
+	Typed []Field
+
 	// Time at which the log entry was created
 	Time time.Time
 
-	// Level the log entry was logged at: Debug, Info, Warn, Error, Fatal or Panic
+	// Level the log entry was logged at: Trace, Debug, Info, Warn, Error, Fatal, Panic or a registered level
 	Level Level
 
 	// Message passed to Write method
 	Message string
+
+	// Context is the context set with WithContext. The fields extracted from it by the
+	// Logger's context extractors are added to the entry when it's written
+	Context context.Context
+
+	// Caller is the calling method, with package name. It's only set when the
+	// Logger is reporting the caller (see Logger.SetReportCaller)
+	Caller *runtime.Frame
+
+	// writerCaller is the caller of WriterLevel, reported for the lines written to
+	// the writer, since they are logged on a separate goroutine
+	writerCaller *runtime.Frame
+
+	// keepTime tells that Time is set by the caller, like the time of a log/slog record, and is
+	// kept when the entry is written. The formatters leave out a zero Time kept this way
+	keepTime bool
+
+	// name is the name of the component the entry was created for with Named
+	name string
 }
 
 // NewEntry creates a new log entry
 func NewEntry(logger *Logger) *Entry {
 	// Default is three fields, give a little extra room
-	return newLogEntry(logger, logger.level, make(Fields, 5))
+	return newLogEntry(logger, logger.Level(), make(Fields, 5))
 }
 
 // NewEntryWithFields creates a new log entry and adds a struct of fields to the entry
 func NewEntryWithFields(logger *Logger, fields Fields) *Entry {
-	return newLogEntry(logger, logger.level, fields)
+	return newLogEntry(logger, logger.Level(), fields)
 }
 
 // NewEntryWithField creates a new log entry and adds a field to the entry
@@ -42,13 +78,19 @@ func NewEntryWithField(logger *Logger, key string, value interface{}) *Entry {
 	//Do not change this to Fields{key:value}. You will end up getting more allocations
 	fields := make(Fields, 1)
 	fields[key] = value
-	return newLogEntry(logger, logger.level, fields)
+	return newLogEntry(logger, logger.Level(), fields)
 }
 
 // AsLevel clones the entry into a new log entry and sets the level to the specified value.
 // Make sure you call this method before calling WithField, WithFields and WithError methods
 func (entry *Entry) AsLevel(level Level) *Entry {
-	return newLogEntry(entry.Logger, level, entry.Data)
+	return entry.clone(level, entry.Data)
+}
+
+// AsTrace clones the entry into a new log entry and sets the level to `trace`
+// Make sure you call this method before calling WithField, WithFields and WithError methods
+func (entry *Entry) AsTrace() *Entry {
+	return entry.AsLevel(TraceLevel)
 }
 
 // AsDebug clones the entry into a new log entry and sets the level to `debug`
@@ -89,7 +131,7 @@ func (entry *Entry) AsPanic() *Entry {
 
 // WithField adds a field to the log entry, note that it doesn't log until you call Write.
 func (entry *Entry) WithField(key string, value interface{}) *Entry {
-	if entry.Level > entry.Logger.Level() {
+	if !entry.isEnabled() {
 		return entry
 	}
 	//Do not change this to Fields{key:value}. You will end up getting more allocations
@@ -100,7 +142,7 @@ func (entry *Entry) WithField(key string, value interface{}) *Entry {
 
 // WithFields adds a struct of fields to the log entry
 func (entry *Entry) WithFields(fields Fields) *Entry {
-	if entry.Level > entry.Logger.Level() {
+	if !entry.isEnabled() {
 		return entry
 	}
 	data := make(Fields, len(entry.Data)+len(fields))
@@ -110,7 +152,19 @@ func (entry *Entry) WithFields(fields Fields) *Entry {
 	for k, v := range fields {
 		data[k] = v
 	}
-	return newLogEntry(entry.Logger, entry.Level, data)
+	return entry.clone(entry.Level, data)
+}
+
+// With adds typed fields to the log entry. The values of the typed fields are neither boxed nor
+// copied into a new map, so With only allocates the new entry and its slice of fields.
+func (entry *Entry) With(fields ...Field) *Entry {
+	if !entry.isEnabled() {
+		return entry
+	}
+	clone := entry.clone(entry.Level, entry.Data)
+	// The full slice expression makes sure the entries never share the appended fields
+	clone.Typed = append(entry.Typed[:len(entry.Typed):len(entry.Typed)], fields...)
+	return clone
 }
 
 // WithError adds an error as single field to the log entry
@@ -118,6 +172,18 @@ func (entry *Entry) WithError(err error) *Entry {
 	return entry.WithField(errorKey, err)
 }
 
+// WithContext adds a context to the log entry. The context is kept by the entries cloned
+// from this one, and the Logger's context extractors pull their fields out of it when
+// the entry is written.
+func (entry *Entry) WithContext(ctx context.Context) *Entry {
+	if !entry.isEnabled() {
+		return entry
+	}
+	clone := entry.clone(entry.Level, entry.Data)
+	clone.Context = ctx
+	return clone
+}
+
 func (entry *Entry) Writef(format string, args ...interface{}) {
 	entry.write(formatted, format, args...)
 }
@@ -130,6 +196,43 @@ func (entry *Entry) Writeln(args ...interface{}) {
 	entry.write(newLine, "", args...)
 }
 
+// WriteWith writes the message with the typed fields added to the fields of the entry, like
+// With(fields...).Write(msg) but without creating a new entry. With the JSON and the text
+// formatters, writing the string, number, boolean and time fields doesn't allocate. The hooks
+// must not keep the entry they are fired with.
+func (entry *Entry) WriteWith(msg string, fields ...Field) {
+	if terminates(entry.Level) {
+		// The entries which exit or panic are never reused
+		entry.With(fields...).write(unformatted, "", msg)
+		return
+	}
+	if !entry.isEnabled() {
+		return
+	}
+
+	scratch := scratchEntryPool.Get().(*Entry)
+	typed := append(append(scratch.Typed[:0], entry.Typed...), fields...)
+	*scratch = *entry
+	scratch.Typed = typed
+	if entry.Logger.sample(entry.Level, entry.Data, typed, formatted, msg, nil) {
+		scratch.log(msg)
+	}
+
+	// The references held by the entry are dropped
+	for i := range typed {
+		typed[i] = Field{}
+	}
+	*scratch = Entry{Typed: typed[:0]}
+	scratchEntryPool.Put(scratch)
+}
+
+// scratchEntryPool holds the entries written by WriteWith
+var scratchEntryPool = sync.Pool{
+	New: func() interface{} {
+		return new(Entry)
+	},
+}
+
 func newLogEntry(logger *Logger, level Level, data Fields) *Entry {
 	return &Entry{
 		Logger: logger,
@@ -138,10 +241,24 @@ func newLogEntry(logger *Logger, level Level, data Fields) *Entry {
 	}
 }
 
+// clone creates a new entry with the same Logger, typed fields and context as the original one
+func (entry *Entry) clone(level Level, data Fields) *Entry {
+	clone := newLogEntry(entry.Logger, level, data)
+	clone.Typed = entry.Typed
+	clone.Context = entry.Context
+	clone.name = entry.name
+	return clone
+}
+
 func (entry *Entry) write(mode formatMode, format string, args ...interface{}) {
-	if entry.Logger.Level() >= entry.Level {
+	if entry.isEnabled() && entry.Logger.sample(entry.Level, entry.Data, entry.Typed, mode, format, args) {
 		message := constructMessage(mode, format, args...)
 		entry.log(message)
+	} else if terminates(entry.Level) {
+		// The fatal and panic entries terminate the program even if they are not written
+		clone := entry.clone(entry.Level, entry.Data)
+		clone.Message = constructMessage(mode, format, args...)
+		entry.Logger.terminate(clone)
 	}
 }
 
@@ -158,33 +275,115 @@ func constructMessage(mode formatMode, format string, args ...interface{}) strin
 }
 
 func (entry *Entry) log(msg string) {
-	entry.Time = time.Now()
+	entry = entry.emit(msg)
+	if terminates(entry.Level) {
+		entry.Logger.terminate(entry)
+	}
+}
+
+// emit writes the entry with the message, without exiting or panicking at the fatal and panic
+// levels. It returns the entry which was written, with the fields extracted from its context.
+func (entry *Entry) emit(msg string) *Entry {
+	if !entry.keepTime {
+		entry.Time = time.Now()
+	}
 	entry.Message = msg
+	entry.Caller = nil
+	if entry.Logger.ReportCaller() {
+		entry.Caller = entry.writerCaller
+		if entry.Caller == nil {
+			entry.Caller = getCaller()
+		}
+	}
 
-	serialized, err := entry.Logger.formatter.Format(entry)
-	if err != nil {
-		entry.Logger.mux.Lock()
-		fmt.Fprintf(os.Stderr, "Failed to obtain reader, %v\n", err)
-		entry.Logger.mux.Unlock()
-	} else {
-		entry.Logger.mux.Lock()
-		_, err = entry.Logger.Out.Write(serialized)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Failed to write to log, %v\n", err)
+	if fields := entry.Logger.extractContextFields(entry.Context); len(fields) > 0 {
+		entry = entry.withExtractedFields(fields)
+	}
+	entry = entry.redact()
+
+	entry.fireHooks()
+
+	entry.Logger.writeEntry(entry)
+	return entry
+}
+
+// fireHooks fires the hooks of the entry's level. The hooks are copied under the lock and fired
+// without it, so that they can log through the same Logger.
+func (entry *Entry) fireHooks() {
+	entry.Logger.mux.Lock()
+	// The hooks are only ever appended, so the slice doesn't change once copied
+	hooks := entry.Logger.hooks[entry.Level]
+	entry.Logger.mux.Unlock()
+
+	for _, hook := range hooks {
+		if err := hook.Fire(entry); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to fire hook: %v\n", err)
+			return
 		}
-		entry.Logger.mux.Unlock()
 	}
+}
 
-	if entry.Level == FatalLevel {
-		Exit(1)
+// withExtractedFields returns a copy of the entry which is about to be written, with the
+// fields extracted from its context. The fields set explicitly on the entry take precedence.
+func (entry *Entry) withExtractedFields(fields Fields) *Entry {
+	for k, v := range entry.Data {
+		fields[k] = v
 	}
+	clone := *entry
+	clone.Data = fields
+	return &clone
+}
+
+// hasTime returns false if the entry has no time, which the formatters leave out
+func (entry *Entry) hasTime() bool {
+	return !entry.keepTime || !entry.Time.IsZero()
+}
+
+// getCaller retrieves the name of the first non-logrus calling function
+func getCaller() *runtime.Frame {
+	return getCallerOutside("runtime")
+}
+
+// getCallerOutside retrieves the first calling function which is neither in logrus nor in the
+// other package
+func getCallerOutside(other string) *runtime.Frame {
+	logrusPackageOnce.Do(func() {
+		pc, _, _, _ := runtime.Caller(0)
+		logrusPackage = getPackageName(runtime.FuncForPC(pc).Name())
+	})
 
-	// To avoid Entry#log() returning a value that only would make sense for
-	// panic() to use in Entry#Panic(), we avoid the allocation by checking
-	// directly here.
-	if entry.Level <= PanicLevel {
-		panic(&entry)
+	pcs := make([]uintptr, maximumCallerDepth)
+	depth := runtime.Callers(2, pcs)
+	frames := runtime.CallersFrames(pcs[:depth])
+
+	for {
+		f, more := frames.Next()
+		pkg := getPackageName(f.Function)
+		if pkg != logrusPackage && pkg != "runtime" && pkg != other {
+			return &f
+		}
+		if !more {
+			break
+		}
 	}
+
+	// if we got here, we failed to find the caller's context
+	return nil
+}
+
+// getPackageName reduces a fully qualified function name to the package name
+func getPackageName(f string) string {
+	for {
+		lastPeriod := strings.LastIndex(f, ".")
+		lastSlash := strings.LastIndex(f, "/")
+		if lastPeriod > lastSlash {
+			f = f[:lastPeriod]
+		} else {
+			break
+		}
+	}
+
+	return f
 }
 
 // String returns the string representation from the reader and ultimately the
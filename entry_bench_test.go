@@ -2,7 +2,9 @@ package logrus
 
 import (
 	"bytes"
+	"io/ioutil"
 	"testing"
+	"time"
 )
 
 func BenchmarkLogEntryWithFieldsNoLog(b *testing.B) {
@@ -32,3 +34,52 @@ func BenchmarkLogEntryWithFieldsLogText(b *testing.B) {
 		entry.AsDebug().WithField("test", "test").Write("Message")
 	}
 }
+
+func BenchmarkLogEntryWithTypedFieldsNoLog(b *testing.B) {
+	logger := New(InfoLevel)
+	logger.Out = &bytes.Buffer{}
+	entry := NewEntry(logger)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		entry.AsDebug().With(String("test", "test"), Int("count", i)).Write("Message")
+	}
+}
+
+func BenchmarkLogEntryWithFieldsMap(b *testing.B) {
+	logger := New(InfoLevel)
+	entry := NewEntry(logger)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		entry.WithFields(Fields{"string": "value", "int": i, "bool": true, "duration": time.Second})
+	}
+}
+
+func BenchmarkLogEntryWithTypedFields(b *testing.B) {
+	logger := New(InfoLevel)
+	entry := NewEntry(logger)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		entry.With(String("string", "value"), Int("int", i), Bool("bool", true), Duration("duration", time.Second))
+	}
+}
+
+func BenchmarkLogEntryWithTypedFieldsLogJSON(b *testing.B) {
+	logger := New(DebugLevel)
+	logger.SetOutput(ioutil.Discard)
+	logger.SetFormatter(new(JSONFormatter))
+	entry := NewEntry(logger).AsDebug()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		entry.WriteWith("Message", String("test", "test"), Int("count", i))
+	}
+}
+
+func BenchmarkLogEntryWithTypedFieldsLogText(b *testing.B) {
+	logger := New(DebugLevel)
+	logger.SetOutput(ioutil.Discard)
+	entry := NewEntry(logger).AsDebug()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		entry.WriteWith("Message", String("test", "test"), Int("count", i))
+	}
+}
@@ -0,0 +1,305 @@
+package logrus
+
+import (
+	"bytes"
+	"fmt"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// maxErrorDepth bounds the number of causes written for an error, in case of cycles
+const maxErrorDepth = 32
+
+// ErrorFormat tells how the TextFormatter writes the fields holding an error.
+type ErrorFormat uint8
+
+const (
+	// ErrorMessage writes the message of the errors only. It's the default.
+	ErrorMessage ErrorFormat = iota
+	// ErrorCompact adds the type, the messages of the causes and the stack trace of the errors
+	// as `<key>.type`, `<key>.causes` and `<key>.stack` fields, on the same line.
+	ErrorCompact
+	// ErrorMultiline writes the type, the causes and the stack traces of the errors on indented
+	// lines following the entry.
+	ErrorMultiline
+)
+
+// ErrorWithStack wraps the error with the stack trace of the caller, which is written by the
+// formatters rendering the errors as structures (see JSONFormatter.StructuredErrors and
+// TextFormatter.ErrorFormat). The wrapper has the message of err, and errors.Is and errors.As
+// see err through it.
+func ErrorWithStack(err error) error {
+	if err == nil {
+		return nil
+	}
+	pcs := make([]uintptr, maximumCallerDepth)
+	n := runtime.Callers(2, pcs)
+	return &stackError{err: err, pcs: pcs[:n]}
+}
+
+// stackError is the error returned by ErrorWithStack
+type stackError struct {
+	err error
+	pcs []uintptr
+}
+
+func (e *stackError) Error() string { return e.err.Error() }
+
+func (e *stackError) Unwrap() error { return e.err }
+
+// Callers returns the program counters of the stack trace, as returned by runtime.Callers
+func (e *stackError) Callers() []uintptr { return e.pcs }
+
+// errorNode is an error and its causes, the way the formatters render them
+type errorNode struct {
+	message  string
+	typeName string
+	stack    []runtime.Frame
+	causes   []*errorNode
+	// joined is true if the causes are the members of a joined error, like errors.Join returns
+	joined bool
+}
+
+// newErrorNode resolves the causes and the stack traces of the error. The wrappers which have
+// the same message as the error they wrap, like the ones only adding a stack trace, are merged
+// with the wrapped error.
+func newErrorNode(err error, depth int) *errorNode {
+	node := &errorNode{message: err.Error(), stack: errorStack(err)}
+	for depth < maxErrorDepth {
+		cause := unwrapError(err)
+		if cause == nil || cause.Error() != node.message {
+			break
+		}
+		if node.stack == nil {
+			node.stack = errorStack(cause)
+		}
+		err = cause
+		depth++
+	}
+	node.typeName = fmt.Sprintf("%T", err)
+	if depth >= maxErrorDepth {
+		return node
+	}
+
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		node.joined = true
+		for _, member := range joined.Unwrap() {
+			if member != nil {
+				node.causes = append(node.causes, newErrorNode(member, depth+1))
+			}
+		}
+	} else if cause := unwrapError(err); cause != nil {
+		node.causes = []*errorNode{newErrorNode(cause, depth+1)}
+	}
+	return node
+}
+
+// unwrapError returns the error wrapped by err with an Unwrap or a pkg/errors style Cause method
+func unwrapError(err error) error {
+	switch e := err.(type) {
+	case interface{ Unwrap() error }:
+		return e.Unwrap()
+	case interface{ Cause() error }:
+		return e.Cause()
+	}
+	return nil
+}
+
+// errorStack returns the stack trace carried by the error, either set by ErrorWithStack or
+// written by a pkg/errors style error formatted with %+v, whose stack trace is written last as
+// a function name and a "\tfile:line" line per frame.
+func errorStack(err error) []runtime.Frame {
+	switch e := err.(type) {
+	case interface{ Callers() []uintptr }:
+		return stackFrames(e.Callers())
+	case fmt.Formatter:
+		return parseErrorStack(fmt.Sprintf("%+v", e))
+	}
+	return nil
+}
+
+// stackFrames resolves the program counters of a stack trace
+func stackFrames(pcs []uintptr) []runtime.Frame {
+	if len(pcs) == 0 {
+		return nil
+	}
+	var frames []runtime.Frame
+	it := runtime.CallersFrames(pcs)
+	for {
+		frame, more := it.Next()
+		frames = append(frames, frame)
+		if !more {
+			return frames
+		}
+	}
+}
+
+// parseErrorStack parses the frames written at the end of the output of a pkg/errors style
+// error, the innermost first:
+//
+//	github.com/example/db.Connect
+//		/src/db/connect.go:23
+func parseErrorStack(output string) []runtime.Frame {
+	lines := strings.Split(strings.TrimRight(output, "\n"), "\n")
+	i := len(lines)
+	for i >= 2 && strings.HasPrefix(lines[i-1], "\t") && !strings.HasPrefix(lines[i-2], "\t") {
+		colon := strings.LastIndexByte(lines[i-1], ':')
+		if colon < 0 {
+			break
+		}
+		if _, err := strconv.Atoi(lines[i-1][colon+1:]); err != nil {
+			break
+		}
+		i -= 2
+	}
+	if i == len(lines) {
+		return nil
+	}
+
+	frames := make([]runtime.Frame, 0, (len(lines)-i)/2)
+	for ; i < len(lines); i += 2 {
+		location := lines[i+1][1:]
+		colon := strings.LastIndexByte(location, ':')
+		line, _ := strconv.Atoi(location[colon+1:])
+		frames = append(frames, runtime.Frame{Function: lines[i], File: location[:colon], Line: line})
+	}
+	return frames
+}
+
+// fieldError returns the error held by a field of the entry, if any
+func fieldError(entry *Entry, key fieldRef) (error, bool) {
+	var value interface{}
+	if key.typed < 0 {
+		value = entry.Data[key.key]
+	} else if field := entry.Typed[key.typed]; field.Type == ErrorType {
+		value = field.Interface
+	}
+	err, ok := value.(error)
+	return err, ok
+}
+
+// appendJSONError appends the error as an object with its message, type, stack trace and causes
+func appendJSONError(b []byte, node *errorNode) []byte {
+	b = append(b, '{')
+	b = appendJSONKey(b, "msg")
+	b = appendJSONString(b, node.message)
+	b = appendJSONKey(b, "type")
+	b = appendJSONString(b, node.typeName)
+	if len(node.stack) > 0 {
+		b = appendJSONKey(b, "stack")
+		b = append(b, '[')
+		for i, frame := range node.stack {
+			if i > 0 {
+				b = append(b, ',')
+			}
+			b = append(b, '{')
+			b = appendJSONKey(b, funcKey)
+			b = appendJSONString(b, frame.Function)
+			b = appendJSONKey(b, fileKey)
+			b = appendJSONString(b, frame.File)
+			b = appendJSONKey(b, "line")
+			b = strconv.AppendInt(b, int64(frame.Line), 10)
+			b = append(b, '}')
+		}
+		b = append(b, ']')
+	}
+	if node.joined {
+		b = appendJSONKey(b, "causes")
+		b = append(b, '[')
+		for i, cause := range node.causes {
+			if i > 0 {
+				b = append(b, ',')
+			}
+			b = appendJSONError(b, cause)
+		}
+		b = append(b, ']')
+	} else if len(node.causes) > 0 {
+		b = appendJSONKey(b, "cause")
+		b = appendJSONError(b, node.causes[0])
+	}
+	return append(b, '}')
+}
+
+// namedError is an error field written after the entry by the ErrorMultiline format
+type namedError struct {
+	key  string
+	node *errorNode
+}
+
+// appendErrorDetails writes the details of an error field on the same line with ErrorCompact,
+// or adds the error to the ones written after the entry with ErrorMultiline. The keys are
+// colored with the color, unless it's nocolor.
+func (f *TextFormatter) appendErrorDetails(b *bytes.Buffer, entry *Entry, key fieldRef, color int, details []namedError) []namedError {
+	if f.ErrorFormat == ErrorMessage {
+		return details
+	}
+	err, ok := fieldError(entry, key)
+	if !ok {
+		return details
+	}
+	node := newErrorNode(err, 0)
+	if f.ErrorFormat == ErrorMultiline {
+		return append(details, namedError{key: key.name, node: node})
+	}
+
+	// The stack trace of the innermost error carrying one is the closest to the failure
+	var causes []string
+	var stack []runtime.Frame
+	node.walk(func(cause *errorNode) {
+		if cause != node {
+			causes = append(causes, cause.message)
+		}
+		if cause.stack != nil {
+			stack = cause.stack
+		}
+	})
+	f.appendErrorKey(b, key.name+".type", color)
+	f.appendString(b, node.typeName)
+	if len(causes) > 0 {
+		f.appendErrorKey(b, key.name+".causes", color)
+		f.appendString(b, strings.Join(causes, "; "))
+	}
+	if len(stack) > 0 {
+		trace := make([]string, len(stack))
+		for i, frame := range stack {
+			trace[i] = fmt.Sprintf("%s(%s:%d)", frame.Function, frame.File, frame.Line)
+		}
+		f.appendErrorKey(b, key.name+".stack", color)
+		f.appendString(b, strings.Join(trace, " "))
+	}
+	return details
+}
+
+func (f *TextFormatter) appendErrorKey(b *bytes.Buffer, key string, color int) {
+	if color == nocolor {
+		b.WriteByte(' ')
+		b.WriteString(key)
+	} else {
+		fmt.Fprintf(b, " \x1b[%dm%s\x1b[0m", color, key)
+	}
+	b.WriteByte('=')
+}
+
+// walk calls fn for the node and its causes, depth first
+func (node *errorNode) walk(fn func(*errorNode)) {
+	fn(node)
+	for _, cause := range node.causes {
+		cause.walk(fn)
+	}
+}
+
+// writeMultilineError writes the error, its stack trace and its causes on indented lines
+func writeMultilineError(b *bytes.Buffer, label string, node *errorNode, indent string) {
+	fmt.Fprintf(b, "%s%s: %s (%s)\n", indent, label, node.message, node.typeName)
+	for _, frame := range node.stack {
+		fmt.Fprintf(b, "%s    at %s (%s:%d)\n", indent, frame.Function, frame.File, frame.Line)
+	}
+	label = "caused by"
+	if node.joined {
+		label = "joined"
+	}
+	for _, cause := range node.causes {
+		writeMultilineError(b, label, cause, indent+"  ")
+	}
+}
@@ -0,0 +1,213 @@
+package logrus
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// joinedError joins several errors, like errors.Join does
+type joinedError []error
+
+func (e joinedError) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "\n")
+}
+
+func (e joinedError) Unwrap() []error { return e }
+
+// pkgFrame, pkgStackTrace and pkgError mimic the errors of github.com/pkg/errors
+type pkgFrame uintptr
+
+type pkgStackTrace []pkgFrame
+
+type pkgError struct {
+	msg   string
+	cause error
+	stack []uintptr
+}
+
+func newPkgError(msg string, cause error) error {
+	pcs := make([]uintptr, 8)
+	n := runtime.Callers(2, pcs)
+	return &pkgError{msg: msg, cause: cause, stack: pcs[:n]}
+}
+
+func (e *pkgError) Error() string { return e.msg }
+
+func (e *pkgError) Cause() error { return e.cause }
+
+func (e *pkgError) StackTrace() pkgStackTrace {
+	trace := make(pkgStackTrace, len(e.stack))
+	for i, pc := range e.stack {
+		trace[i] = pkgFrame(pc)
+	}
+	return trace
+}
+
+// Format writes the causes, the message and the stack trace with %+v, like errors.Wrap does
+func (e *pkgError) Format(s fmt.State, verb rune) {
+	if verb == 'v' && s.Flag('+') {
+		if e.cause != nil {
+			fmt.Fprintf(s, "%+v\n", e.cause)
+		}
+		io.WriteString(s, e.msg)
+		for _, frame := range e.StackTrace() {
+			fn := runtime.FuncForPC(uintptr(frame) - 1)
+			file, line := fn.FileLine(uintptr(frame) - 1)
+			fmt.Fprintf(s, "\n%s\n\t%s:%d", fn.Name(), file, line)
+		}
+		return
+	}
+	io.WriteString(s, e.msg)
+}
+
+func formatJSONError(t *testing.T, err error) map[string]interface{} {
+	logger := New(InfoLevel)
+	entry := logger.WithError(err)
+	entry.Message = "failed"
+	serialized, ferr := (&JSONFormatter{StructuredErrors: true}).Format(entry)
+	require.NoError(t, ferr)
+
+	var fields map[string]interface{}
+	require.NoError(t, json.Unmarshal(serialized, &fields))
+	return fields
+}
+
+func TestStructuredJSONErrorChain(t *testing.T) {
+	err := fmt.Errorf("query failed: %w", errors.New("timeout"))
+	fields := formatJSONError(t, err)
+
+	assert.Equal(t, map[string]interface{}{
+		"msg":  "query failed: timeout",
+		"type": "*fmt.wrapError",
+		"cause": map[string]interface{}{
+			"msg":  "timeout",
+			"type": "*errors.errorString",
+		},
+	}, fields["error"])
+}
+
+func TestStructuredJSONJoinedErrors(t *testing.T) {
+	err := joinedError{errors.New("disk full"), errors.New("network down")}
+	fields := formatJSONError(t, err)
+
+	structured := fields["error"].(map[string]interface{})
+	assert.Equal(t, "logrus.joinedError", structured["type"])
+	causes := structured["causes"].([]interface{})
+	require.Len(t, causes, 2)
+	assert.Equal(t, "disk full", causes[0].(map[string]interface{})["msg"])
+	assert.Equal(t, "network down", causes[1].(map[string]interface{})["msg"])
+}
+
+func TestStructuredJSONErrorStack(t *testing.T) {
+	err := ErrorWithStack(fmt.Errorf("query failed: %w", errors.New("timeout")))
+	fields := formatJSONError(t, err)
+
+	structured := fields["error"].(map[string]interface{})
+	// The wrapper adding the stack trace is merged with the error it wraps
+	assert.Equal(t, "*fmt.wrapError", structured["type"])
+	stack := structured["stack"].([]interface{})
+	require.NotEmpty(t, stack)
+	top := stack[0].(map[string]interface{})
+	assert.Equal(t, "github.com/xitonix/logrus.TestStructuredJSONErrorStack", top["func"])
+	assert.Contains(t, top["file"], "error_format_test.go")
+	assert.NotZero(t, top["line"])
+	assert.Equal(t, "timeout", structured["cause"].(map[string]interface{})["msg"])
+}
+
+func TestStructuredJSONPkgErrorsStack(t *testing.T) {
+	err := newPkgError("connect: refused", errors.New("refused"))
+	fields := formatJSONError(t, err)
+
+	structured := fields["error"].(map[string]interface{})
+	assert.Equal(t, "*logrus.pkgError", structured["type"])
+	stack := structured["stack"].([]interface{})
+	require.NotEmpty(t, stack)
+	assert.Equal(t, "github.com/xitonix/logrus.TestStructuredJSONPkgErrorsStack", stack[0].(map[string]interface{})["func"])
+	assert.Equal(t, "refused", structured["cause"].(map[string]interface{})["msg"])
+}
+
+func newPkgErrorInHelper() error {
+	return newPkgError("refused", nil)
+}
+
+func TestStructuredJSONPkgErrorsStackOfEachCause(t *testing.T) {
+	err := newPkgError("connect: refused", newPkgErrorInHelper())
+	fields := formatJSONError(t, err)
+
+	structured := fields["error"].(map[string]interface{})
+	stack := structured["stack"].([]interface{})
+	require.NotEmpty(t, stack)
+	assert.Equal(t, "github.com/xitonix/logrus.TestStructuredJSONPkgErrorsStackOfEachCause", stack[0].(map[string]interface{})["func"])
+	cause := structured["cause"].(map[string]interface{})
+	stack = cause["stack"].([]interface{})
+	require.NotEmpty(t, stack)
+	assert.Equal(t, "github.com/xitonix/logrus.newPkgErrorInHelper", stack[0].(map[string]interface{})["func"])
+}
+
+func TestParseErrorStack(t *testing.T) {
+	assert.Nil(t, parseErrorStack("failed"))
+	assert.Nil(t, parseErrorStack("failed\n\tindented"))
+	assert.Equal(t, []runtime.Frame{
+		{Function: "main.connect", File: "/src/main.go", Line: 12},
+		{Function: "main.main", File: "C:/src/main.go", Line: 7},
+	}, parseErrorStack("refused\nmain.open\n\t/src/open.go:3\nconnect: refused\nmain.connect\n\t/src/main.go:12\nmain.main\n\tC:/src/main.go:7"))
+}
+
+func TestStructuredJSONTypedErrorField(t *testing.T) {
+	var buffer bytes.Buffer
+	logger := New(InfoLevel)
+	logger.SetOutput(&buffer)
+	logger.SetFormatter(&JSONFormatter{StructuredErrors: true, ExpandDottedKeys: true})
+	logger.AsError().With(Any("db.err", errors.New("timeout"))).Write("failed")
+
+	var fields map[string]interface{}
+	require.NoError(t, json.Unmarshal(buffer.Bytes(), &fields))
+	assert.Equal(t, map[string]interface{}{"err": map[string]interface{}{"msg": "timeout", "type": "*errors.errorString"}}, fields["db"])
+}
+
+func TestTextErrorFormats(t *testing.T) {
+	err := ErrorWithStack(fmt.Errorf("query failed: %w", errors.New("timeout")))
+	entry := New(InfoLevel).WithError(err)
+	entry.Message = "failed"
+
+	serialized, ferr := (&TextFormatter{DisableColors: true, DisableTimestamp: true}).Format(entry)
+	require.NoError(t, ferr)
+	assert.Equal(t, "level=info msg=failed error=\"query failed: timeout\"\n", string(serialized))
+
+	serialized, ferr = (&TextFormatter{DisableColors: true, DisableTimestamp: true, ErrorFormat: ErrorCompact}).Format(entry)
+	require.NoError(t, ferr)
+	text := string(serialized)
+	assert.True(t, strings.HasPrefix(text, "level=info msg=failed error=\"query failed: timeout\" error.type=\"*fmt.wrapError\" error.causes=timeout error.stack=\"github.com/xitonix/logrus.TestTextErrorFormats("), text)
+	assert.Equal(t, 1, strings.Count(text, "\n"))
+
+	serialized, ferr = (&TextFormatter{DisableColors: true, DisableTimestamp: true, ErrorFormat: ErrorMultiline}).Format(entry)
+	require.NoError(t, ferr)
+	lines := strings.Split(strings.TrimSpace(string(serialized)), "\n")
+	assert.Equal(t, "level=info msg=failed error=\"query failed: timeout\"", lines[0])
+	assert.Equal(t, "  error: query failed: timeout (*fmt.wrapError)", lines[1])
+	assert.True(t, strings.HasPrefix(lines[2], "      at github.com/xitonix/logrus.TestTextErrorFormats ("), lines[2])
+	assert.Equal(t, "    caused by: timeout (*errors.errorString)", lines[len(lines)-1])
+}
+
+func TestTextJoinedErrorMultiline(t *testing.T) {
+	entry := New(InfoLevel).WithError(joinedError{errors.New("disk full"), errors.New("network down")})
+	entry.Message = "failed"
+
+	serialized, err := (&TextFormatter{DisableColors: true, DisableTimestamp: true, ErrorFormat: ErrorMultiline}).Format(entry)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(serialized)), "\n")
+	assert.Equal(t, []string{"    joined: disk full (*errors.errorString)", "    joined: network down (*errors.errorString)"}, lines[len(lines)-2:])
+}
@@ -0,0 +1,84 @@
+package logrus
+
+import (
+	"os"
+)
+
+// ExitOptions configures what a Logger does once a fatal or a panic entry is written.
+type ExitOptions struct {
+	// ExitFunc is called with the ExitCode after a fatal entry is written. The default runs the
+	// exit handlers, reporting their failures with the Logger, waits for the asynchronous
+	// loggers and exits, like Exit. A function which doesn't exit downgrades the fatal entries to
+	// errors: the caller goes on once the entry is written.
+	ExitFunc func(code int)
+
+	// ExitCode is the exit code of the fatal entries. The default is 1.
+	ExitCode int
+
+	// PanicFunc is called with the entry after a panic entry is written. The default panics
+	// with a pointer to the entry, a **Entry. A function which doesn't panic downgrades the
+	// panic entries to errors. The entry must not be retained once PanicFunc returns.
+	PanicFunc func(entry *Entry)
+}
+
+// SetExitOptions sets what the Logger does once a fatal or a panic entry is written. The entries
+// at these levels terminate the program even if they are not written, because the level of the
+// Logger is above them or they are suppressed by the sampling.
+//
+// The tests can check the fatal entries without exiting:
+//
+//	logger.SetExitOptions(logrus.ExitOptions{ExitFunc: func(code int) { exitCode = code }})
+func (logger *Logger) SetExitOptions(opts ExitOptions) {
+	logger.exitOptions.Store(&opts)
+}
+
+func (logger *Logger) loadExitOptions() *ExitOptions {
+	opts, _ := logger.exitOptions.Load().(*ExitOptions)
+	if opts == nil {
+		return &ExitOptions{}
+	}
+	return opts
+}
+
+// terminates returns true if the entries at the level exit or panic once written
+func terminates(level Level) bool {
+	return level == FatalLevel || level <= PanicLevel
+}
+
+// terminate exits or panics after the entry at the fatal or the panic level has been written
+func (logger *Logger) terminate(entry *Entry) {
+	if entry.Level == FatalLevel {
+		logger.exit()
+		return
+	}
+
+	logger.flushWithin(exitFlushTimeout)
+	if opts := logger.loadExitOptions(); opts.PanicFunc != nil {
+		opts.PanicFunc(entry)
+		return
+	}
+	panic(&entry)
+}
+
+// exit terminates the program like a fatal entry does
+func (logger *Logger) exit() {
+	logger.flushSampling()
+	opts := logger.loadExitOptions()
+	code := opts.ExitCode
+	if code == 0 {
+		code = 1
+	}
+	if opts.ExitFunc != nil {
+		opts.ExitFunc(code)
+		return
+	}
+	exit(logger, code)
+}
+
+// exit runs the exit handlers, reporting their failures with the logger, waits for the
+// asynchronous loggers to write their queued entries and terminates the program
+func exit(logger *Logger, code int) {
+	runHandlers(logger)
+	flushAsyncLoggers(exitFlushTimeout)
+	os.Exit(code)
+}
@@ -0,0 +1,88 @@
+package logrus
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExitFuncReplacesTheExit(t *testing.T) {
+	var buffer bytes.Buffer
+	logger := New(InfoLevel)
+	logger.SetOutput(&buffer)
+	logger.SetFormatter(new(JSONFormatter))
+
+	var codes []int
+	logger.SetExitOptions(ExitOptions{ExitFunc: func(code int) { codes = append(codes, code) }})
+
+	logger.Fatal("first")
+	logger.AsFatal().WithField("attempt", 2).Write("second")
+	assert.Equal(t, []int{1, 1}, codes)
+	assert.Contains(t, buffer.String(), `"msg":"first"`)
+	assert.Contains(t, buffer.String(), `"msg":"second"`)
+}
+
+func TestExitCode(t *testing.T) {
+	logger := New(InfoLevel)
+	logger.SetOutput(new(bytes.Buffer))
+
+	var exitCode int
+	logger.SetExitOptions(ExitOptions{ExitCode: 3, ExitFunc: func(code int) { exitCode = code }})
+	logger.Fatalf("failed %d times", 2)
+	assert.Equal(t, 3, exitCode)
+}
+
+func TestPanicFunc(t *testing.T) {
+	logger := New(InfoLevel)
+	logger.SetOutput(new(bytes.Buffer))
+
+	var messages []string
+	logger.SetExitOptions(ExitOptions{PanicFunc: func(entry *Entry) { messages = append(messages, entry.Message) }})
+	assert.NotPanics(t, func() {
+		logger.Panicln("first")
+		logger.AsPanic().Write("second")
+	})
+	assert.Equal(t, []string{"first", "second"}, messages)
+}
+
+func TestDefaultPanicValue(t *testing.T) {
+	logger := New(InfoLevel)
+	logger.SetOutput(new(bytes.Buffer))
+
+	defer func() {
+		value := recover()
+		entry, ok := value.(**Entry)
+		require.True(t, ok, "unexpected panic value %#v", value)
+		assert.Equal(t, "boom", (*entry).Message)
+	}()
+	logger.Panic("boom")
+}
+
+func TestExitAtDisabledLevel(t *testing.T) {
+	var buffer bytes.Buffer
+	logger := New(PanicLevel)
+	logger.SetOutput(&buffer)
+
+	var exitCode int
+	logger.SetExitOptions(ExitOptions{ExitFunc: func(code int) { exitCode = code }})
+	logger.Fatal("failed")
+	assert.Equal(t, 1, exitCode)
+
+	exitCode = 0
+	logger.AsFatal().Write("failed")
+	assert.Equal(t, 1, exitCode)
+	assert.Empty(t, buffer.String())
+}
+
+func TestStandardLoggerExitOptions(t *testing.T) {
+	defer std.SetExitOptions(ExitOptions{})
+	defer std.SetOutput(std.Out)
+	std.SetOutput(new(bytes.Buffer))
+
+	var exitCode int
+	SetExitOptions(ExitOptions{ExitCode: 4, ExitFunc: func(code int) { exitCode = code }})
+	Fatal("failed")
+	assert.Equal(t, 4, exitCode)
+}
@@ -1,7 +1,9 @@
 package logrus
 
 import (
+	"context"
 	"io"
+	"time"
 )
 
 var (
@@ -51,11 +53,64 @@ func UseTextFormatter() {
 }
 // SetLevel sets the standard Logger level.
 func SetLevel(level Level) {
-	std.mux.Lock()
-	defer std.mux.Unlock()
-	std.level = level
+	std.SetLevel(level)
+}
+
+// SetLevelVar makes the standard Logger use the level of the variable.
+func SetLevelVar(v *LevelVar) {
+	std.SetLevelVar(v)
+}
+
+// SetLevelFor sets the standard Logger level for the duration d, then restores the previous level.
+func SetLevelFor(level Level, d time.Duration) {
+	std.SetLevelFor(level, d)
+}
+
+// SetComponentLevel sets the level of a component of the standard Logger.
+func SetComponentLevel(name string, level Level) {
+	std.SetComponentLevel(name, level)
+}
+
+// SetComponentLevels replaces the levels of the components of the standard Logger.
+func SetComponentLevels(levels map[string]Level) {
+	std.SetComponentLevels(levels)
+}
+
+// SetExitOptions sets what the standard Logger does once a fatal or a panic entry is written.
+func SetExitOptions(opts ExitOptions) {
+	std.SetExitOptions(opts)
 }
 
+// SetReportCaller sets whether the standard Logger will include the calling
+// method as a field.
+func SetReportCaller(include bool) {
+	std.SetReportCaller(include)
+}
+
+// SetSampling sets the sampling options of the standard logger.
+func SetSampling(opts SamplingOptions) {
+	std.SetSampling(opts)
+}
+
+// SetRedactor sets the Redactor of the standard logger.
+func SetRedactor(redactor *Redactor) {
+	std.SetRedactor(redactor)
+}
+
+// AddHook adds a hook to the standard Logger hooks.
+func AddHook(hook Hook) {
+	std.AddHook(hook)
+}
+
+// AddSink adds an output to the standard Logger.
+func AddSink(sink Sink) {
+	std.AddSink(sink)
+}
+
+// IsLevelEnabled checks if the standard Logger writes the entries at the level.
+func IsLevelEnabled(level Level) bool {
+	return std.IsLevelEnabled(level)
+}
 
 // AsLevel creates a new entry from the standard Logger and sets the level to the specified value.
 // Make sure you call this method before calling WithField, WithFields and WithError methods
@@ -63,6 +118,12 @@ func AsLevel(level Level) *Entry {
 	return std.AsLevel(level)
 }
 
+// AsTrace creates a new entry from the standard Logger and sets the level to `trace`
+// Make sure you call this method before calling WithField, WithFields and WithError methods
+func AsTrace() *Entry {
+	return std.AsLevel(TraceLevel)
+}
+
 // AsDebug creates a new entry from the standard Logger and sets the level to `debug`
 // Make sure you call this method before calling WithField, WithFields and WithError methods
 func AsDebug() *Entry {
@@ -99,11 +160,31 @@ func AsPanic() *Entry {
 	return std.AsLevel(PanicLevel)
 }
 
+// Named creates an entry from the standard Logger for the component with the name.
+func Named(name string) *Entry {
+	return std.Named(name)
+}
+
 // WithError creates an entry from the standard Logger and adds an error to the entry.
 func WithError(err error) *Entry {
 	return std.WithField(errorKey, err)
 }
 
+// With creates an entry from the standard Logger and adds typed fields to it.
+func With(fields ...Field) *Entry {
+	return std.With(fields...)
+}
+
+// WithContext creates an entry from the standard Logger and adds a context to it.
+func WithContext(ctx context.Context) *Entry {
+	return std.WithContext(ctx)
+}
+
+// AddContextExtractor registers a context extractor on the standard Logger.
+func AddContextExtractor(extractor ContextExtractor) {
+	std.AddContextExtractor(extractor)
+}
+
 // WithField creates an entry from the standard Logger and adds a field to
 // the entry. If you want multiple fields, use `WithFields`.
 //
@@ -121,6 +202,23 @@ func WithFields(fields Fields) *Entry {
 	return std.WithFields(fields)
 }
 
+// Recover recovers from a panic and logs it on the standard Logger. See Logger.Recover.
+func Recover(opts RecoverOptions) {
+	if value := recover(); value != nil {
+		std.logRecovered(value, opts)
+	}
+}
+
+// Go runs fn on a new goroutine, recovering and logging its panics on the standard Logger.
+func Go(fn func()) {
+	std.Go(fn)
+}
+
+// Trace logs a Message at level Trace on the standard Logger.
+func Trace(args ...interface{}) {
+	std.Trace(args...)
+}
+
 // Debug logs a Message at level Debug on the standard Logger.
 func Debug(args ...interface{}) {
 	std.Debug(args...)
@@ -151,6 +249,11 @@ func Fatal(args ...interface{}) {
 	std.Fatal(args...)
 }
 
+// Tracef logs a Message at level Trace on the standard Logger.
+func Tracef(format string, args ...interface{}) {
+	std.Tracef(format, args...)
+}
+
 // Debugf logs a Message at level Debug on the standard Logger.
 func Debugf(format string, args ...interface{}) {
 	std.Debugf(format, args...)
@@ -181,6 +284,11 @@ func Fatalf(format string, args ...interface{}) {
 	std.Fatalf(format, args...)
 }
 
+// Traceln logs a Message at level Trace on the standard Logger.
+func Traceln(args ...interface{}) {
+	std.Traceln(args...)
+}
+
 // Debugln logs a Message at level Debug on the standard Logger.
 func Debugln(args ...interface{}) {
 	std.Debugln(args...)
@@ -210,3 +318,9 @@ func Panicln(args ...interface{}) {
 func Fatalln(args ...interface{}) {
 	std.Fatalln(args...)
 }
+
+// RedirectStdLog redirects the default logger of the standard log package to the standard Logger.
+// See Logger.RedirectStdLog.
+func RedirectStdLog(level Level) func() {
+	return std.RedirectStdLog(level)
+}
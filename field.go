@@ -0,0 +1,214 @@
+package logrus
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+)
+
+// FieldType tells how the value of a typed field is stored.
+type FieldType uint8
+
+const (
+	// UnknownType is the type of the zero Field, which is ignored by the formatters.
+	UnknownType FieldType = iota
+	// StringType fields store their value in String.
+	StringType
+	// Int64Type fields store their value in Integer.
+	Int64Type
+	// Float64Type fields store the IEEE 754 bits of their value in Integer.
+	Float64Type
+	// BoolType fields store 1 for true and 0 for false in Integer.
+	BoolType
+	// DurationType fields store the nanoseconds in Integer.
+	DurationType
+	// TimeType fields store the Unix nanoseconds in Integer and the *time.Location in Interface,
+	// or the time.Time itself in Interface if it can't be represented in nanoseconds.
+	TimeType
+	// ErrorType fields store the error in Interface.
+	ErrorType
+	// AnyType fields store the value in Interface. They are encoded with reflection.
+	AnyType
+)
+
+// minTimeNanos and maxTimeNanos bound the times which can be stored in Unix nanoseconds
+var (
+	minTimeNanos = time.Unix(0, math.MinInt64)
+	maxTimeNanos = time.Unix(0, math.MaxInt64)
+)
+
+// Field is a typed field added to the entries with With. Unlike the values of Fields, the values
+// of the typed fields are not boxed in interfaces, so creating them doesn't allocate and the
+// formatters encode them without reflection. Use the constructors, like String or Int, to
+// create the fields.
+type Field struct {
+	Key       string
+	Type      FieldType
+	Integer   int64
+	String    string
+	Interface interface{}
+}
+
+// String creates a string field.
+func String(key string, value string) Field {
+	return Field{Key: key, Type: StringType, String: value}
+}
+
+// Int creates an integer field.
+func Int(key string, value int) Field {
+	return Int64(key, int64(value))
+}
+
+// Int64 creates a 64 bit integer field.
+func Int64(key string, value int64) Field {
+	return Field{Key: key, Type: Int64Type, Integer: value}
+}
+
+// Float64 creates a floating point field.
+func Float64(key string, value float64) Field {
+	return Field{Key: key, Type: Float64Type, Integer: int64(math.Float64bits(value))}
+}
+
+// Bool creates a boolean field.
+func Bool(key string, value bool) Field {
+	var i int64
+	if value {
+		i = 1
+	}
+	return Field{Key: key, Type: BoolType, Integer: i}
+}
+
+// Duration creates a time.Duration field.
+func Duration(key string, value time.Duration) Field {
+	return Field{Key: key, Type: DurationType, Integer: int64(value)}
+}
+
+// Time creates a time.Time field.
+func Time(key string, value time.Time) Field {
+	if value.Before(minTimeNanos) || value.After(maxTimeNanos) {
+		return Field{Key: key, Type: TimeType, Interface: value}
+	}
+	return Field{Key: key, Type: TimeType, Integer: value.UnixNano(), Interface: value.Location()}
+}
+
+// Err creates an error field, with the same key as the one set by WithError.
+func Err(err error) Field {
+	return Field{Key: errorKey, Type: ErrorType, Interface: err}
+}
+
+// Any creates a field of any type. The values of the types which have a constructor are
+// stored like the constructor would do, the other ones are encoded with reflection.
+func Any(key string, value interface{}) Field {
+	switch v := value.(type) {
+	case string:
+		return String(key, v)
+	case int:
+		return Int(key, v)
+	case int64:
+		return Int64(key, v)
+	case float64:
+		return Float64(key, v)
+	case bool:
+		return Bool(key, v)
+	case time.Duration:
+		return Duration(key, v)
+	case time.Time:
+		return Time(key, v)
+	case error:
+		return Field{Key: key, Type: ErrorType, Interface: v}
+	default:
+		return Field{Key: key, Type: AnyType, Interface: v}
+	}
+}
+
+// Value returns the value of the field, boxed in an interface.
+func (f Field) Value() interface{} {
+	switch f.Type {
+	case StringType:
+		return f.String
+	case Int64Type:
+		return f.Integer
+	case Float64Type:
+		return f.float64()
+	case BoolType:
+		return f.Integer == 1
+	case DurationType:
+		return time.Duration(f.Integer)
+	case TimeType:
+		return f.time()
+	default:
+		return f.Interface
+	}
+}
+
+func (f Field) float64() float64 {
+	return math.Float64frombits(uint64(f.Integer))
+}
+
+func (f Field) time() time.Time {
+	if loc, ok := f.Interface.(*time.Location); ok {
+		return time.Unix(0, f.Integer).In(loc)
+	}
+	t, _ := f.Interface.(time.Time)
+	return t
+}
+
+// text returns the value of the field the way fmt.Sprint would format it
+func (f Field) text() string {
+	switch f.Type {
+	case StringType:
+		return f.String
+	case Int64Type:
+		return strconv.FormatInt(f.Integer, 10)
+	case Float64Type:
+		return strconv.FormatFloat(f.float64(), 'g', -1, 64)
+	case BoolType:
+		return strconv.FormatBool(f.Integer == 1)
+	case DurationType:
+		return time.Duration(f.Integer).String()
+	case TimeType:
+		return f.time().String()
+	case ErrorType:
+		if err, ok := f.Interface.(error); ok {
+			return err.Error()
+		}
+	}
+	return fmt.Sprint(f.Interface)
+}
+
+// timeTextLayout is the layout of time.Time.String
+const timeTextLayout = "2006-01-02 15:04:05.999999999 -0700 MST"
+
+// appendText appends the value of the field like text does, without allocating for the numbers,
+// the booleans and the times
+func (f *Field) appendText(b []byte) []byte {
+	switch f.Type {
+	case StringType:
+		return append(b, f.String...)
+	case Int64Type:
+		return strconv.AppendInt(b, f.Integer, 10)
+	case Float64Type:
+		return strconv.AppendFloat(b, f.float64(), 'g', -1, 64)
+	case BoolType:
+		return strconv.AppendBool(b, f.Integer == 1)
+	case TimeType:
+		return f.time().AppendFormat(b, timeTextLayout)
+	}
+	return append(b, f.text()...)
+}
+
+// typedFieldIndex returns the index of the last typed field with the key, or -1
+func typedFieldIndex(fields []Field, key string) int {
+	for i := len(fields) - 1; i >= 0; i-- {
+		if fields[i].Key == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// isShadowed returns true if the i-th typed field is overridden by a later field with the same key
+func isShadowed(fields []Field, i int) bool {
+	return fields[i].Type == UnknownType || typedFieldIndex(fields[i+1:], fields[i].Key) >= 0
+}
@@ -0,0 +1,196 @@
+package logrus
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTypedFieldValues(t *testing.T) {
+	now := time.Date(2018, 3, 8, 10, 30, 0, 123, time.UTC)
+	err := errors.New("failure")
+
+	testCases := []struct {
+		field    Field
+		expected interface{}
+	}{
+		{String("k", "v"), "v"},
+		{Int("k", -42), int64(-42)},
+		{Int64("k", math.MaxInt64), int64(math.MaxInt64)},
+		{Float64("k", 1.5), 1.5},
+		{Bool("k", true), true},
+		{Bool("k", false), false},
+		{Duration("k", time.Second), time.Second},
+		{Time("k", now), now},
+		{Time("k", time.Time{}), time.Time{}},
+		{Err(err), err},
+		{Any("k", []int{1, 2}), []int{1, 2}},
+		{Any("k", "v"), "v"},
+	}
+
+	for _, tc := range testCases {
+		assert.Equal(t, tc.expected, tc.field.Value())
+	}
+	assert.Equal(t, errorKey, Err(err).Key)
+	assert.Equal(t, StringType, Any("k", "v").Type)
+}
+
+func TestTypedFieldsJSON(t *testing.T) {
+	now := time.Date(2018, 3, 8, 10, 30, 0, 123, time.FixedZone("ACST", 34200))
+
+	LogAndAssertJSON(t, func(log *Logger) {
+		log.With(
+			String("string", "a \"quoted\" <value>\n"),
+			Int("int", 42),
+			Float64("float", 0.25),
+			Float64("nan", math.NaN()),
+			Bool("bool", true),
+			Duration("duration", time.Millisecond),
+			Time("time_field", now),
+			Err(errors.New("failure")),
+			Any("any", map[string]int{"one": 1}),
+		).Write("test")
+	}, func(fields Fields) {
+		assert.Equal(t, "a \"quoted\" <value>\n", fields["string"])
+		assert.Equal(t, float64(42), fields["int"])
+		assert.Equal(t, 0.25, fields["float"])
+		assert.Equal(t, "NaN", fields["nan"])
+		assert.Equal(t, true, fields["bool"])
+		assert.Equal(t, float64(time.Millisecond), fields["duration"])
+		assert.Equal(t, "2018-03-08T10:30:00.000000123+09:30", fields["time_field"])
+		assert.Equal(t, "failure", fields[errorKey])
+		assert.Equal(t, map[string]interface{}{"one": float64(1)}, fields["any"])
+		assert.Equal(t, "test", fields[messageKey])
+	})
+}
+
+func TestTypedFieldsJSONEncodingMatchesEncodingJSON(t *testing.T) {
+	strs := []string{"plain", "tab\tand\x01control", "html <&>", "unicode é ☃", " ", "invalid \xff"}
+	for _, s := range strs {
+		expected, err := json.Marshal(s)
+		require.NoError(t, err)
+		assert.Equal(t, string(expected), string(appendJSONString(nil, s)))
+	}
+
+	floats := []float64{0, 1, -1.5, 1e-7, 123456789, 1e21, 3.14159}
+	for _, f := range floats {
+		expected, err := json.Marshal(f)
+		require.NoError(t, err)
+		assert.Equal(t, string(expected), string(appendJSONFloat(nil, f, 64)))
+	}
+}
+
+func TestTypedFieldsText(t *testing.T) {
+	LogAndAssertText(t, func(log *Logger) {
+		log.With(
+			String("string", "value"),
+			Int("int", 42),
+			Float64("float", 0.25),
+			Bool("bool", false),
+			Duration("duration", 1500*time.Millisecond),
+			Err(errors.New("failure")),
+		).Write("test")
+	}, func(fields map[string]string) {
+		assert.Equal(t, "value", strings.TrimSpace(fields["string"]))
+		assert.Equal(t, "42", fields["int"])
+		assert.Equal(t, "0.25", fields["float"])
+		assert.Equal(t, "false", fields["bool"])
+		assert.Equal(t, "1.5s", fields["duration"])
+		assert.Equal(t, "failure", strings.TrimSpace(fields[errorKey]))
+	})
+}
+
+func TestTypedFieldsAreSortedWithTheOtherFields(t *testing.T) {
+	var buffer bytes.Buffer
+	logger := New(InfoLevel)
+	logger.SetOutput(&buffer)
+	logger.SetFormatter(&TextFormatter{DisableColors: true, DisableTimestamp: true})
+
+	logger.WithField("b", 2).With(Int("c", 3), Int("a", 1)).Write("test")
+
+	assert.Equal(t, "level=info msg=test a=1 b=2 c=3\n", buffer.String())
+}
+
+func TestTypedFieldsTakePrecedence(t *testing.T) {
+	LogAndAssertJSON(t, func(log *Logger) {
+		log.WithField("key", "map").With(String("key", "first"), String("key", "typed")).Write("test")
+	}, func(fields Fields) {
+		assert.Equal(t, "typed", fields["key"])
+	})
+
+	LogAndAssertText(t, func(log *Logger) {
+		log.WithField("key", "map").With(String("key", "typed")).Write("test")
+	}, func(fields map[string]string) {
+		assert.Equal(t, "typed", strings.TrimSpace(fields["key"]))
+	})
+}
+
+func TestTypedFieldsClashingWithDefaultFields(t *testing.T) {
+	LogAndAssertJSON(t, func(log *Logger) {
+		log.With(String(levelKey, "user value")).Write("test")
+	}, func(fields Fields) {
+		assert.Equal(t, "info", fields[levelKey])
+		assert.Equal(t, "user value", fields["fields.level"])
+	})
+}
+
+func TestWithDoesNotModifyTheOriginalEntry(t *testing.T) {
+	logger := New(InfoLevel)
+	entry := logger.With(String("a", "1"))
+	first := entry.With(String("b", "2"))
+	second := entry.With(String("c", "3"))
+
+	assert.Len(t, entry.Typed, 1)
+	assert.Equal(t, "b", first.Typed[1].Key)
+	assert.Equal(t, "c", second.Typed[1].Key)
+	assert.Equal(t, entry.Typed, first.AsWarning().Typed[:1])
+	assert.Len(t, first.WithField("d", 4).Typed, 2)
+}
+
+func TestWithOnDisabledLevel(t *testing.T) {
+	logger := New(InfoLevel)
+	entry := logger.AsDebug()
+	assert.True(t, entry == entry.With(String("key", "value")))
+}
+
+func TestWriteWithDoesNotAllocate(t *testing.T) {
+	if raceEnabled {
+		t.Skip("the allocations are not stable with the race detector")
+	}
+	for name, formatter := range map[string]Formatter{"json": new(JSONFormatter), "text": new(TextFormatter)} {
+		t.Run(name, func(t *testing.T) {
+			logger := New(InfoLevel)
+			logger.SetOutput(ioutil.Discard)
+			logger.SetFormatter(formatter)
+			entry := logger.AsInfo().With(String("service", "api"))
+
+			allocs := testing.AllocsPerRun(100, func() {
+				entry.WriteWith("test", Int("a", 1234), String("b", "needs quoting"), Bool("c", true),
+					Float64("d", 4.5), Int64("f", 6), Time("g", time.Date(2018, 3, 8, 10, 30, 0, 0, time.UTC)))
+			})
+			assert.Zero(t, allocs)
+		})
+	}
+}
+
+func TestWriteWith(t *testing.T) {
+	var buffer bytes.Buffer
+	logger := New(InfoLevel)
+	logger.SetOutput(&buffer)
+	logger.SetFormatter(&TextFormatter{DisableTimestamp: true})
+	entry := logger.AsWarning().With(String("service", "api"))
+
+	entry.WriteWith("first", Int("attempt", 1))
+	entry.WriteWith("second", String("service", "db"))
+	logger.AsDebug().WriteWith("disabled", Int("attempt", 3))
+	assert.Equal(t, "level=warning msg=first attempt=1 service=api\nlevel=warning msg=second service=db\n", buffer.String())
+	assert.Len(t, entry.Typed, 1)
+}
@@ -1,6 +1,12 @@
 package logrus
 
-import "time"
+import (
+	"bytes"
+	"fmt"
+	"runtime"
+	"sync"
+	"time"
+)
 
 const defaultTimestampFormat = time.RFC3339
 
@@ -9,6 +15,12 @@ const (
 	messageKey = "msg"
 	timeKey    = "time"
 	levelKey   = "level"
+	funcKey    = "func"
+	fileKey    = "file"
+	loggerKey  = "logger"
+	panicKey   = "panic"
+	stackKey   = "stack"
+	prefixKey  = "prefix"
 )
 
 // The formatter interface is used to implement a custom formatter. It takes an
@@ -17,6 +29,7 @@ const (
 // * `entry.Data["msg"]`. The Message passed from Info, Warn, Error ..
 // * `entry.Data["time"]`. The timestamp.
 // * `entry.Data["level"]. The level the entry was logged at.
+// * `entry.Caller`. The calling method, if the Logger is reporting the caller.
 //
 // Any additional fields added with `WithField` or `WithFields` are also in
 // `entry.Data`. Format is expected to return an array of bytes which are then
@@ -25,28 +38,133 @@ type Formatter interface {
 	Format(*Entry) ([]byte, error)
 }
 
-// This is to not silently overwrite `time`, `msg` and `level` fields when
-// dumping it. If this code wasn't there doing:
+// callerFields returns the values of the `func` and `file` fields for the
+// caller of the entry. An empty value means the field has to be omitted.
+func callerFields(caller *runtime.Frame, prettyfier func(*runtime.Frame) (function string, file string)) (string, string) {
+	if prettyfier != nil {
+		return prettyfier(caller)
+	}
+	return caller.Function, fmt.Sprintf("%s:%d", caller.File, caller.Line)
+}
+
+// prefixedKey returns the key of a field in the output. This is to not silently overwrite `time`,
+// `msg` and `level` fields when dumping it, nor `func` and `file` when reporting the caller:
 //
 //  logrus.WithField("level", 1).Info("hello")
 //
-// Would just silently drop the user provided level. Instead with this code
-// it'll logged as:
+// is logged as:
 //
 //  {"level": "info", "fields.level": 1, "msg": "hello", "time": "..."}
 //
-// It's not exported because it's still using Data in an opinionated way. It's to
-// avoid code duplication between the two default formatters.
-func prefixFieldClashes(data Fields) {
-	if t, ok := data[timeKey]; ok {
-		data["fields.time"] = t
+// The entry itself is left untouched, since it's shared by all the formatters writing it.
+func prefixedKey(key string, reportCaller bool) string {
+	switch key {
+	case timeKey, messageKey, levelKey:
+		return "fields." + key
+	case funcKey, fileKey:
+		if reportCaller {
+			return "fields." + key
+		}
 	}
+	return key
+}
+
+// appendFormatter is implemented by the formatters which can append the formatted entry to a
+// buffer. The Logger formats the entries into a reused buffer with it, so that writing them
+// doesn't allocate.
+type appendFormatter interface {
+	appendFormat(b []byte, entry *Entry) ([]byte, error)
+}
 
-	if m, ok := data[messageKey]; ok {
-		data["fields.msg"] = m
+// maxPooledBuffer is the capacity above which the buffers are not returned to their pool, so a
+// few huge entries don't keep a lot of memory around
+const maxPooledBuffer = 64 << 10
+
+// formatEncoder holds the buffers reused to format the entries
+type formatEncoder struct {
+	buf    []byte
+	text   bytes.Buffer
+	fields fieldRefs
+}
+
+var formatEncoderPool = sync.Pool{
+	New: func() interface{} {
+		return &formatEncoder{buf: make([]byte, 0, 1024)}
+	},
+}
+
+func newFormatEncoder() *formatEncoder {
+	return formatEncoderPool.Get().(*formatEncoder)
+}
+
+func (e *formatEncoder) release() {
+	if cap(e.buf) > maxPooledBuffer || e.text.Cap() > maxPooledBuffer {
+		return
+	}
+	e.buf = e.buf[:0]
+	e.text.Reset()
+	// The references to the entries are dropped
+	for i := range e.fields {
+		e.fields[i] = fieldRef{}
+	}
+	e.fields = e.fields[:0]
+	formatEncoderPool.Put(e)
+}
+
+// Len, Less and Swap sort the fields of the encoder. They are implemented on the pointer, so
+// sorting doesn't allocate.
+func (e *formatEncoder) Len() int           { return len(e.fields) }
+func (e *formatEncoder) Less(i, j int) bool { return e.fields[i].name < e.fields[j].name }
+func (e *formatEncoder) Swap(i, j int)      { e.fields[i], e.fields[j] = e.fields[j], e.fields[i] }
+
+// formatCopy formats the entry with the formatter into a new slice
+func formatCopy(formatter appendFormatter, entry *Entry) ([]byte, error) {
+	enc := newFormatEncoder()
+	defer enc.release()
+	b, err := formatter.appendFormat(enc.buf[:0], entry)
+	if err != nil {
+		return nil, err
 	}
+	enc.buf = b
+	serialized := make([]byte, len(b))
+	copy(serialized, b)
+	return serialized, nil
+}
+
+// fieldRef refers to a field of Data by its key, or to a typed field by its index. The name
+// is the key written in the output.
+type fieldRef struct {
+	name  string
+	key   string
+	typed int
+}
 
-	if l, ok := data[levelKey]; ok {
-		data["fields.level"] = l
+// fieldRefs sorts the fields by name
+type fieldRefs []fieldRef
+
+func (r fieldRefs) Len() int           { return len(r) }
+func (r fieldRefs) Less(i, j int) bool { return r[i].name < r[j].name }
+func (r fieldRefs) Swap(i, j int)      { r[i], r[j] = r[j], r[i] }
+
+// appendFieldRefs appends the fields of Data which are not overridden by a typed field, followed
+// by the typed fields which are not overridden by a later one. The keys of the typed fields, and
+// the keys of Data if prefixData is set, are prefixed when they clash with the default fields.
+func appendFieldRefs(refs fieldRefs, entry *Entry, prefixData bool) fieldRefs {
+	reportCaller := entry.Caller != nil
+	for k := range entry.Data {
+		if typedFieldIndex(entry.Typed, k) >= 0 {
+			continue
+		}
+		name := k
+		if prefixData {
+			name = prefixedKey(k, reportCaller)
+		}
+		refs = append(refs, fieldRef{name: name, key: k, typed: -1})
+	}
+	for i, field := range entry.Typed {
+		if !isShadowed(entry.Typed, i) {
+			refs = append(refs, fieldRef{name: prefixedKey(field.Key, reportCaller), key: field.Key, typed: i})
+		}
 	}
+	return refs
 }
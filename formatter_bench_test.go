@@ -1,6 +1,7 @@
 package logrus
 
 import (
+	"encoding/json"
 	"fmt"
 	"testing"
 	"time"
@@ -79,6 +80,56 @@ func BenchmarkLargeJSONFormatter(b *testing.B) {
 	doBenchmark(b, &JSONFormatter{}, largeFields)
 }
 
+func BenchmarkSmallMarshalJSONFormatter(b *testing.B) {
+	doBenchmark(b, &marshalJSONFormatter{}, smallFields)
+}
+
+func BenchmarkLargeMarshalJSONFormatter(b *testing.B) {
+	doBenchmark(b, &marshalJSONFormatter{}, largeFields)
+}
+
+func BenchmarkErrorJSONFormatter(b *testing.B) {
+	doBenchmark(b, &JSONFormatter{}, errorFields)
+}
+
+func BenchmarkErrorMarshalJSONFormatter(b *testing.B) {
+	doBenchmark(b, &marshalJSONFormatter{}, errorFields)
+}
+
+// marshalJSONFormatter is the previous implementation of JSONFormatter, which copies the fields
+// into a map and marshals it with encoding/json. It's kept to compare the performance.
+type marshalJSONFormatter struct {
+	JSONFormatter
+}
+
+func (f *marshalJSONFormatter) Format(entry *Entry) ([]byte, error) {
+	data := make(Fields, len(entry.Data)+3)
+	for k, v := range entry.Data {
+		switch v := v.(type) {
+		case error:
+			data[prefixedKey(k, entry.Caller != nil)] = v.Error()
+		default:
+			data[prefixedKey(k, entry.Caller != nil)] = v
+		}
+	}
+
+	timestampFormat := f.TimestampFormat
+	if timestampFormat == "" {
+		timestampFormat = defaultTimestampFormat
+	}
+	if !f.DisableTimestamp {
+		data[f.FieldMap.resolve(timeKey)] = entry.Time.Format(timestampFormat)
+	}
+	data[f.FieldMap.resolve(messageKey)] = entry.Message
+	data[f.FieldMap.resolve(levelKey)] = entry.Level.String()
+
+	serialized, err := json.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal fields to JSON, %v", err)
+	}
+	return append(serialized, '\n'), nil
+}
+
 func doBenchmark(b *testing.B, formatter Formatter, fields Fields) {
 	logger := New(InfoLevel)
 
@@ -91,6 +142,7 @@ func doBenchmark(b *testing.B, formatter Formatter, fields Fields) {
 	}
 	var d []byte
 	var err error
+	b.ReportAllocs()
 	for i := 0; i < b.N; i++ {
 		d, err = formatter.Format(entry)
 		if err != nil {
@@ -99,3 +151,23 @@ func doBenchmark(b *testing.B, formatter Formatter, fields Fields) {
 		b.SetBytes(int64(len(d)))
 	}
 }
+
+func BenchmarkTypedFieldsJSONEncoding(b *testing.B) {
+	fields := []Field{
+		String("string", "value"),
+		Int("int", 42),
+		Float64("float", 0.5),
+		Bool("bool", true),
+		Duration("duration", time.Second),
+		Time("time", time.Date(2018, 3, 8, 10, 30, 0, 0, time.UTC)),
+	}
+	buf := make([]byte, 0, 1024)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		buf = buf[:0]
+		for _, field := range fields {
+			buf = appendJSONString(buf, field.Key)
+			buf, _ = appendJSONField(buf, field)
+		}
+	}
+}
@@ -0,0 +1,273 @@
+package logrus
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type TestHook struct {
+	Fired bool
+}
+
+func (hook *TestHook) Fire(entry *Entry) error {
+	hook.Fired = true
+	return nil
+}
+
+func (hook *TestHook) Levels() []Level {
+	return AllLevels
+}
+
+func TestHookFires(t *testing.T) {
+	hook := new(TestHook)
+
+	LogAndAssertJSON(t, func(log *Logger) {
+		log.AddHook(hook)
+		assert.Equal(t, hook.Fired, false)
+
+		log.Print("test")
+	}, func(fields Fields) {
+		assert.Equal(t, hook.Fired, true)
+	})
+}
+
+type ModifyHook struct {
+}
+
+func (hook *ModifyHook) Fire(entry *Entry) error {
+	entry.Data["wow"] = "whale"
+	return nil
+}
+
+func (hook *ModifyHook) Levels() []Level {
+	return AllLevels
+}
+
+func TestHookCanModifyEntry(t *testing.T) {
+	hook := new(ModifyHook)
+
+	LogAndAssertJSON(t, func(log *Logger) {
+		log.AddHook(hook)
+		log.WithField("wow", "elephant").Write("test")
+	}, func(fields Fields) {
+		assert.Equal(t, fields["wow"], "whale")
+	})
+}
+
+func TestCanFireMultipleHooks(t *testing.T) {
+	hook1 := new(ModifyHook)
+	hook2 := new(TestHook)
+
+	LogAndAssertJSON(t, func(log *Logger) {
+		log.AddHook(hook1)
+		log.AddHook(hook2)
+
+		log.WithField("wow", "elephant").Write("test")
+	}, func(fields Fields) {
+		assert.Equal(t, fields["wow"], "whale")
+		assert.Equal(t, hook2.Fired, true)
+	})
+}
+
+type SingleLevelModifyHook struct {
+	ModifyHook
+}
+
+func (h *SingleLevelModifyHook) Levels() []Level {
+	return []Level{InfoLevel}
+}
+
+func TestHookFiresOnlyOnRegisteredLevels(t *testing.T) {
+	h := new(SingleLevelModifyHook)
+
+	LogAndAssertJSON(t, func(log *Logger) {
+		log.AddHook(h)
+		log.AsWarning().WithField("wow", "elephant").Write("test")
+	}, func(fields Fields) {
+		assert.Equal(t, fields["wow"], "elephant")
+	})
+
+	LogAndAssertJSON(t, func(log *Logger) {
+		log.AddHook(h)
+		log.AsInfo().WithField("wow", "elephant").Write("test")
+	}, func(fields Fields) {
+		assert.Equal(t, fields["wow"], "whale")
+	})
+}
+
+type ErrorHook struct {
+	Fired bool
+}
+
+func (hook *ErrorHook) Fire(entry *Entry) error {
+	hook.Fired = true
+	return nil
+}
+
+func (hook *ErrorHook) Levels() []Level {
+	return []Level{
+		ErrorLevel,
+	}
+}
+
+func TestErrorHookShouldntFireOnInfo(t *testing.T) {
+	hook := new(ErrorHook)
+
+	LogAndAssertJSON(t, func(log *Logger) {
+		log.AddHook(hook)
+		log.Info("test")
+	}, func(fields Fields) {
+		assert.Equal(t, hook.Fired, false)
+	})
+}
+
+func TestErrorHookShouldFireOnError(t *testing.T) {
+	hook := new(ErrorHook)
+
+	LogAndAssertJSON(t, func(log *Logger) {
+		log.AddHook(hook)
+		log.Error("test")
+	}, func(fields Fields) {
+		assert.Equal(t, hook.Fired, true)
+	})
+}
+
+type FailingHook struct{}
+
+func (hook *FailingHook) Fire(entry *Entry) error {
+	return errors.New("hook failed")
+}
+
+func (hook *FailingHook) Levels() []Level {
+	return AllLevels
+}
+
+func TestFailingHookStillWritesEntry(t *testing.T) {
+	stderr := os.Stderr
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Stderr = w
+	defer func() {
+		os.Stderr = stderr
+	}()
+
+	var buffer bytes.Buffer
+	logger := New(InfoLevel)
+	logger.SetOutput(&buffer)
+	logger.AddHook(new(FailingHook))
+	logger.Info("test")
+
+	w.Close()
+	reported, _ := ioutil.ReadAll(r)
+
+	assert.Contains(t, string(reported), "Failed to fire hook: hook failed")
+	assert.Contains(t, buffer.String(), "msg=test")
+}
+
+func TestReplaceHooks(t *testing.T) {
+	old, cur := &TestHook{}, &TestHook{}
+
+	logger := New(InfoLevel)
+	logger.SetOutput(ioutil.Discard)
+	logger.AddHook(old)
+
+	hooks := make(LevelHooks)
+	hooks.Add(cur)
+	replaced := logger.ReplaceHooks(hooks)
+	replaced.Fire(InfoLevel, &Entry{Logger: logger})
+
+	logger.Info("test")
+
+	assert.Equal(t, old.Fired, true)
+	assert.Equal(t, cur.Fired, true)
+}
+
+type HookCallFunc struct {
+	F func()
+}
+
+func (h *HookCallFunc) Levels() []Level {
+	return AllLevels
+}
+
+func (h *HookCallFunc) Fire(e *Entry) error {
+	h.F()
+	return nil
+}
+
+func TestHookFireOrder(t *testing.T) {
+	checkers := []string{}
+	h := LevelHooks{}
+	h.Add(&HookCallFunc{F: func() { checkers = append(checkers, "first hook") }})
+	h.Add(&HookCallFunc{F: func() { checkers = append(checkers, "second hook") }})
+	h.Add(&HookCallFunc{F: func() { checkers = append(checkers, "third hook") }})
+
+	if err := h.Fire(InfoLevel, &Entry{}); err != nil {
+		t.Error("unexpected error:", err)
+	}
+	assert.Equal(t, []string{"first hook", "second hook", "third hook"}, checkers)
+}
+
+func TestAddHookRace(t *testing.T) {
+	var wg sync.WaitGroup
+	wg.Add(2)
+	hook := new(ErrorHook)
+	LogAndAssertJSON(t, func(logger *Logger) {
+		go func() {
+			defer wg.Done()
+			logger.AddHook(hook)
+		}()
+		go func() {
+			defer wg.Done()
+			logger.Error("test")
+		}()
+		wg.Wait()
+	}, func(fields Fields) {
+		// the line may have been logged
+		// before the hook was added, so we can't
+		// actually assert on the hook
+	})
+}
+
+// loggingHook logs the errors at the warning level through the logger it's added to
+type loggingHook struct {
+	logger *Logger
+}
+
+func (h *loggingHook) Levels() []Level {
+	return []Level{ErrorLevel}
+}
+
+func (h *loggingHook) Fire(e *Entry) error {
+	h.logger.Warningf("hook fired for %q", e.Message)
+	return nil
+}
+
+func TestHookCanLogThroughItsLogger(t *testing.T) {
+	var buffer bytes.Buffer
+	logger := New(InfoLevel)
+	logger.SetOutput(&buffer)
+	logger.SetFormatter(&TextFormatter{DisableColors: true, DisableTimestamp: true})
+	logger.AddHook(&loggingHook{logger: logger})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		logger.Error("test")
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the hook logging through its logger deadlocked")
+	}
+	assert.Equal(t, "level=warning msg=\"hook fired for \\\"test\\\"\"\nlevel=error msg=test\n", buffer.String())
+}
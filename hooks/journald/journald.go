@@ -0,0 +1,197 @@
+// Package journald sends the logrus entries to the systemd journal, with the journald native
+// protocol:
+//
+//	if journald.Available() {
+//		sink, err := journald.NewSink("", logrus.InfoLevel, nil)
+//		if err == nil {
+//			logger.AddSink(sink)
+//		}
+//	}
+//
+// The entries are written as journal fields: MESSAGE, PRIORITY, SYSLOG_IDENTIFIER, CODE_FILE,
+// CODE_LINE and CODE_FUNC when the Logger reports the caller, and a field per entry field.
+package journald
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/xitonix/logrus"
+)
+
+// DefaultSocket is the path of the journald native protocol socket.
+const DefaultSocket = "/run/systemd/journal/socket"
+
+const (
+	// maxFieldNameLength is the longest field name accepted by journald
+	maxFieldNameLength = 64
+
+	// fieldPrefix prefixes the entry fields whose names would be invalid or clash with the
+	// fields written by the Formatter
+	fieldPrefix = "FIELD_"
+)
+
+// reservedFields are written by the Formatter
+var reservedFields = map[string]bool{
+	"MESSAGE":           true,
+	"PRIORITY":          true,
+	"SYSLOG_IDENTIFIER": true,
+	"CODE_FILE":         true,
+	"CODE_LINE":         true,
+	"CODE_FUNC":         true,
+}
+
+// Priority maps a logrus level to the syslog priority of the journal entries. The levels
+// registered between logrus.WarnLevel and logrus.InfoLevel are mapped to notice (5), the other
+// registered levels to the priority of the next predefined level, and the levels more verbose
+// than logrus.InfoLevel to debug (7).
+func Priority(level logrus.Level) int {
+	switch {
+	case logrus.PanicLevel.Enables(level):
+		return 1
+	case logrus.FatalLevel.Enables(level):
+		return 2
+	case logrus.ErrorLevel.Enables(level):
+		return 3
+	case logrus.WarnLevel.Enables(level):
+		return 4
+	case !level.Enables(logrus.InfoLevel):
+		return 5
+	case level == logrus.InfoLevel:
+		return 6
+	default:
+		return 7
+	}
+}
+
+// FieldName turns the key of an entry field into a valid journal field name: the name is
+// uppercased, the characters other than letters, digits and underscores are replaced with
+// underscores, and the leading underscores, which are reserved for the fields set by journald,
+// are removed. The names starting with a digit, empty or clashing with the fields written by
+// the Formatter get the FIELD_ prefix. The names are truncated to 64 characters.
+func FieldName(key string) string {
+	b := make([]byte, 0, len(key))
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		switch {
+		case c >= 'a' && c <= 'z':
+			c -= 'a' - 'A'
+		case c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '_':
+		default:
+			c = '_'
+		}
+		if c == '_' && len(b) == 0 {
+			continue
+		}
+		b = append(b, c)
+	}
+
+	name := string(b)
+	if name == "" || name[0] >= '0' && name[0] <= '9' || reservedFields[name] {
+		name = fieldPrefix + name
+	}
+	if len(name) > maxFieldNameLength {
+		name = name[:maxFieldNameLength]
+	}
+	return name
+}
+
+// Formatter serializes the entries with the journald native protocol.
+type Formatter struct {
+	// Identifier is the SYSLOG_IDENTIFIER of the entries. The default is the name of the
+	// executable.
+	Identifier string
+
+	once       sync.Once
+	identifier string
+}
+
+// Format renders a single log entry as a journal entry.
+func (f *Formatter) Format(entry *logrus.Entry) ([]byte, error) {
+	f.once.Do(func() {
+		f.identifier = f.Identifier
+		if f.identifier == "" {
+			f.identifier = filepath.Base(os.Args[0])
+		}
+	})
+
+	b := make([]byte, 0, 256)
+	b = appendField(b, "MESSAGE", entry.Message)
+	b = appendField(b, "PRIORITY", strconv.Itoa(Priority(entry.Level)))
+	b = appendField(b, "SYSLOG_IDENTIFIER", f.identifier)
+	if entry.Caller != nil {
+		b = appendField(b, "CODE_FILE", entry.Caller.File)
+		b = appendField(b, "CODE_LINE", strconv.Itoa(entry.Caller.Line))
+		b = appendField(b, "CODE_FUNC", entry.Caller.Function)
+	}
+
+	fields := make(map[string]string, len(entry.Data)+len(entry.Typed))
+	for k, v := range entry.Data {
+		fields[FieldName(k)] = valueString(v)
+	}
+	for _, field := range entry.Typed {
+		if field.Type != logrus.UnknownType {
+			fields[FieldName(field.Key)] = valueString(field.Value())
+		}
+	}
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		b = appendField(b, name, fields[name])
+	}
+	return b, nil
+}
+
+// appendField serializes a field. The values containing a newline are written as binary data,
+// prefixed by their little endian 64 bit length.
+func appendField(b []byte, name string, value string) []byte {
+	b = append(b, name...)
+	if strings.IndexByte(value, '\n') < 0 {
+		b = append(b, '=')
+		b = append(b, value...)
+		return append(b, '\n')
+	}
+	b = append(b, '\n')
+	var size [8]byte
+	binary.LittleEndian.PutUint64(size[:], uint64(len(value)))
+	b = append(b, size[:]...)
+	b = append(b, value...)
+	return append(b, '\n')
+}
+
+func valueString(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case error:
+		return v.Error()
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	}
+	return fmt.Sprint(value)
+}
+
+// NewSink opens the journal socket at path, or at DefaultSocket if path is empty, and returns
+// a sink sending the entries at or above the level, formatted by the formatter. A nil
+// formatter uses the default settings. The Out of the sink is the *Writer, which should be
+// closed once the Logger is no longer used.
+func NewSink(path string, level logrus.Level, formatter *Formatter) (logrus.Sink, error) {
+	w, err := Dial(path)
+	if err != nil {
+		return logrus.Sink{}, err
+	}
+	if formatter == nil {
+		formatter = &Formatter{}
+	}
+	return logrus.Sink{Formatter: formatter, Out: w, Level: level}, nil
+}
@@ -0,0 +1,96 @@
+package journald
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xitonix/logrus"
+)
+
+// parseEntry decodes a journal entry serialized with the native protocol
+func parseEntry(t *testing.T, b []byte) map[string]string {
+	fields := make(map[string]string)
+	for len(b) > 0 {
+		i := bytes.IndexAny(b, "=\n")
+		require.True(t, i > 0, "invalid field in %q", b)
+		name := string(b[:i])
+		if b[i] == '=' {
+			end := bytes.IndexByte(b, '\n')
+			require.True(t, end > i)
+			fields[name] = string(b[i+1 : end])
+			b = b[end+1:]
+			continue
+		}
+		b = b[i+1:]
+		require.True(t, len(b) >= 8)
+		size := int(binary.LittleEndian.Uint64(b))
+		b = b[8:]
+		require.True(t, len(b) > size)
+		require.Equal(t, byte('\n'), b[size])
+		fields[name] = string(b[:size])
+		b = b[size+1:]
+	}
+	return fields
+}
+
+func TestFormat(t *testing.T) {
+	f := &Formatter{Identifier: "app"}
+	entry := logrus.NewEntryWithFields(logrus.New(logrus.InfoLevel), logrus.Fields{
+		"user":       "walrus",
+		"multi-line": "first\nsecond",
+		"_trusted":   "value",
+		"message":    "field",
+		"9lives":     9,
+		"err":        errors.New("failure"),
+	})
+	entry.Typed = []logrus.Field{logrus.Int("count", 3)}
+	entry.Level = logrus.WarnLevel
+	entry.Message = "hello"
+	entry.Caller = &runtime.Frame{File: "main.go", Line: 42, Function: "main.main"}
+
+	b, err := f.Format(entry)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"MESSAGE":           "hello",
+		"PRIORITY":          "4",
+		"SYSLOG_IDENTIFIER": "app",
+		"CODE_FILE":         "main.go",
+		"CODE_LINE":         "42",
+		"CODE_FUNC":         "main.main",
+		"USER":              "walrus",
+		"MULTI_LINE":        "first\nsecond",
+		"TRUSTED":           "value",
+		"FIELD_MESSAGE":     "field",
+		"FIELD_9LIVES":      "9",
+		"ERR":               "failure",
+		"COUNT":             "3",
+	}, parseEntry(t, b))
+	assert.True(t, bytes.HasPrefix(b, []byte("MESSAGE=hello\nPRIORITY=4\n")))
+}
+
+func TestFieldName(t *testing.T) {
+	assert.Equal(t, "REQUEST_ID", FieldName("request.id"))
+	assert.Equal(t, "FIELD_", FieldName("__"))
+	assert.Equal(t, "FIELD_PRIORITY", FieldName("priority"))
+	assert.Equal(t, strings.Repeat("K", 64), FieldName(strings.Repeat("k", 100)))
+}
+
+// noticeLevel is registered between the warning and the info levels
+var noticeLevel = logrus.MustRegisterLevel("notice", logrus.WarnLevel)
+
+func TestPriority(t *testing.T) {
+	assert.Equal(t, 1, Priority(logrus.PanicLevel))
+	assert.Equal(t, 2, Priority(logrus.FatalLevel))
+	assert.Equal(t, 3, Priority(logrus.ErrorLevel))
+	assert.Equal(t, 4, Priority(logrus.WarnLevel))
+	assert.Equal(t, 6, Priority(logrus.InfoLevel))
+	assert.Equal(t, 7, Priority(logrus.DebugLevel))
+	assert.Equal(t, 7, Priority(logrus.TraceLevel))
+	assert.Equal(t, 5, Priority(noticeLevel))
+}
@@ -0,0 +1,126 @@
+//go:build linux
+// +build linux
+
+package journald
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// Writer sends every Write, which must be a journal entry serialized by the Formatter, as one
+// datagram to the journal socket. The entries too large for a datagram are written to a sealed
+// memfd, or to a deleted temporary file on the kernels without memfd, whose descriptor is sent
+// instead. It's safe for concurrent use.
+type Writer struct {
+	conn *net.UnixConn
+	addr *net.UnixAddr
+}
+
+// Available returns true if the journal socket exists, which is the case when the application
+// runs on a system managed by systemd.
+func Available() bool {
+	_, err := os.Stat(DefaultSocket)
+	return err == nil
+}
+
+// Dial opens a socket to send the entries to the journal socket at path, or at DefaultSocket
+// if path is empty. The socket is not connected, so the writes keep working when journald is
+// restarted.
+func Dial(path string) (*Writer, error) {
+	if path == "" {
+		path = DefaultSocket
+	}
+	if _, err := os.Stat(path); err != nil {
+		return nil, err
+	}
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Net: "unixgram"})
+	if err != nil {
+		return nil, err
+	}
+	return &Writer{conn: conn, addr: &net.UnixAddr{Name: path, Net: "unixgram"}}, nil
+}
+
+// Write sends the entry to the journal.
+func (w *Writer) Write(p []byte) (int, error) {
+	_, _, err := w.conn.WriteMsgUnix(p, nil, w.addr)
+	if err == nil {
+		return len(p), nil
+	}
+	if !isTooLarge(err) {
+		return 0, err
+	}
+	if err := w.sendFile(p); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// sendFile writes the entry to a file and sends its descriptor
+func (w *Writer) sendFile(p []byte) error {
+	file, err := memfd(p)
+	if err != nil {
+		if file, err = tempFile(p); err != nil {
+			return err
+		}
+	}
+	defer file.Close()
+
+	_, _, err = w.conn.WriteMsgUnix(nil, syscall.UnixRights(int(file.Fd())), w.addr)
+	return err
+}
+
+// memfd writes p to a sealed memory file
+func memfd(p []byte) (*os.File, error) {
+	fd, err := unix.MemfdCreate("logrus-journal", unix.MFD_ALLOW_SEALING|unix.MFD_CLOEXEC)
+	if err != nil {
+		return nil, err
+	}
+	file := os.NewFile(uintptr(fd), "logrus-journal")
+	if _, err := file.Write(p); err != nil {
+		file.Close()
+		return nil, err
+	}
+	seals := unix.F_SEAL_SHRINK | unix.F_SEAL_GROW | unix.F_SEAL_WRITE | unix.F_SEAL_SEAL
+	if _, err := unix.FcntlInt(file.Fd(), unix.F_ADD_SEALS, seals); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return file, nil
+}
+
+// tempFile writes p to a temporary file, which is deleted right away, in /dev/shm if it exists
+func tempFile(p []byte) (*os.File, error) {
+	file, err := ioutil.TempFile("/dev/shm", "logrus-journal.")
+	if err != nil {
+		if file, err = ioutil.TempFile("", "logrus-journal."); err != nil {
+			return nil, err
+		}
+	}
+	os.Remove(file.Name())
+	if _, err := file.Write(p); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return file, nil
+}
+
+// isTooLarge returns true if the write failed because the datagram exceeds the socket buffer
+func isTooLarge(err error) bool {
+	if opErr, ok := err.(*net.OpError); ok {
+		err = opErr.Err
+	}
+	if sysErr, ok := err.(*os.SyscallError); ok {
+		err = sysErr.Err
+	}
+	return err == syscall.EMSGSIZE || err == syscall.ENOBUFS
+}
+
+// Close closes the socket.
+func (w *Writer) Close() error {
+	return w.conn.Close()
+}
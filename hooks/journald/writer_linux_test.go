@@ -0,0 +1,95 @@
+//go:build linux
+// +build linux
+
+package journald
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xitonix/logrus"
+)
+
+// listen creates a stand-in of the journal socket
+func listen(t *testing.T) (*net.UnixConn, string) {
+	dir, err := ioutil.TempDir("", "journald")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	path := filepath.Join(dir, "socket")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	return conn, path
+}
+
+func TestSink(t *testing.T) {
+	conn, path := listen(t)
+
+	sink, err := NewSink(path, logrus.InfoLevel, &Formatter{Identifier: "app"})
+	require.NoError(t, err)
+	defer sink.Out.(*Writer).Close()
+
+	logger := logrus.New(logrus.InfoLevel)
+	logger.SetOutput(ioutil.Discard)
+	logger.AddSink(sink)
+	logger.AsError().WithField("user", "walrus").Write("access denied")
+
+	buf := make([]byte, 4096)
+	n, err := conn.Read(buf)
+	require.NoError(t, err)
+	fields := parseEntry(t, buf[:n])
+	assert.Equal(t, "access denied", fields["MESSAGE"])
+	assert.Equal(t, "3", fields["PRIORITY"])
+	assert.Equal(t, "app", fields["SYSLOG_IDENTIFIER"])
+	assert.Equal(t, "walrus", fields["USER"])
+}
+
+func TestLargeEntriesArePassedAsFiles(t *testing.T) {
+	conn, path := listen(t)
+
+	w, err := Dial(path)
+	require.NoError(t, err)
+	defer w.Close()
+
+	message := strings.Repeat("x", 4<<20)
+	entry := appendField(nil, "MESSAGE", message)
+	n, err := w.Write(entry)
+	require.NoError(t, err)
+	assert.Equal(t, len(entry), n)
+
+	buf := make([]byte, 16)
+	oob := make([]byte, syscall.CmsgSpace(4))
+	n, oobn, _, _, err := conn.ReadMsgUnix(buf, oob)
+	require.NoError(t, err)
+	assert.Equal(t, 0, n)
+
+	messages, err := syscall.ParseSocketControlMessage(oob[:oobn])
+	require.NoError(t, err)
+	require.Len(t, messages, 1)
+	fds, err := syscall.ParseUnixRights(&messages[0])
+	require.NoError(t, err)
+	require.Len(t, fds, 1)
+
+	file := os.NewFile(uintptr(fds[0]), "journal")
+	defer file.Close()
+	_, err = file.Seek(0, 0)
+	require.NoError(t, err)
+	content, err := ioutil.ReadAll(file)
+	require.NoError(t, err)
+	assert.Equal(t, message, parseEntry(t, content)["MESSAGE"])
+}
+
+func TestDialMissingSocket(t *testing.T) {
+	_, err := Dial(filepath.Join(os.TempDir(), "missing-journal-socket"))
+	assert.Error(t, err)
+}
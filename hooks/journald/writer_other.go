@@ -0,0 +1,31 @@
+//go:build !linux
+// +build !linux
+
+package journald
+
+import "errors"
+
+var errUnsupported = errors.New("journald: the journal is only available on linux")
+
+// Writer sends the entries to the journal. It's only implemented on linux.
+type Writer struct{}
+
+// Available returns false, since the journal is only available on linux.
+func Available() bool {
+	return false
+}
+
+// Dial returns an error, since the journal is only available on linux.
+func Dial(path string) (*Writer, error) {
+	return nil, errUnsupported
+}
+
+// Write returns an error, since the journal is only available on linux.
+func (w *Writer) Write(p []byte) (int, error) {
+	return 0, errUnsupported
+}
+
+// Close does nothing.
+func (w *Writer) Close() error {
+	return nil
+}
@@ -0,0 +1,337 @@
+// Package syslog sends the logrus entries to a syslog server. The Formatter frames the entries
+// as RFC 5424 or RFC 3164 messages and the Writer sends them over a unix socket, UDP or TCP:
+//
+//	sink, err := logrus_syslog.NewSink("udp", "localhost:514", logrus.InfoLevel, &logrus_syslog.Formatter{
+//		Facility: logrus_syslog.Local0,
+//		AppName:  "api",
+//	})
+//	if err != nil {
+//		...
+//	}
+//	logger.AddSink(sink)
+package syslog
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/xitonix/logrus"
+)
+
+// Facility is the syslog facility of the messages.
+type Facility int
+
+// The syslog facilities, as defined by RFC 5424.
+const (
+	Kern Facility = iota
+	User
+	Mail
+	Daemon
+	Auth
+	Syslog
+	LPR
+	News
+	UUCP
+	Cron
+	AuthPriv
+	FTP
+	NTP
+	Security
+	Console
+	SolarisCron
+	Local0
+	Local1
+	Local2
+	Local3
+	Local4
+	Local5
+	Local6
+	Local7
+)
+
+// Severity is the syslog severity of the messages.
+type Severity int
+
+// The syslog severities, as defined by RFC 5424.
+const (
+	Emergency Severity = iota
+	Alert
+	Critical
+	Error
+	Warning
+	Notice
+	Informational
+	Debug
+)
+
+// SeverityOf maps a logrus level to its syslog severity. The levels registered between
+// logrus.WarnLevel and logrus.InfoLevel are mapped to Notice, the other registered levels to
+// the severity of the next predefined level, and the levels more verbose than logrus.InfoLevel
+// to Debug.
+func SeverityOf(level logrus.Level) Severity {
+	switch {
+	case logrus.PanicLevel.Enables(level):
+		return Alert
+	case logrus.FatalLevel.Enables(level):
+		return Critical
+	case logrus.ErrorLevel.Enables(level):
+		return Error
+	case logrus.WarnLevel.Enables(level):
+		return Warning
+	case !level.Enables(logrus.InfoLevel):
+		return Notice
+	case level == logrus.InfoLevel:
+		return Informational
+	default:
+		return Debug
+	}
+}
+
+// Protocol is the format of the syslog messages.
+type Protocol int
+
+const (
+	// RFC5424 is the format of https://tools.ietf.org/html/rfc5424. The fields are written as
+	// structured data.
+	RFC5424 Protocol = iota
+	// RFC3164 is the legacy BSD format of https://tools.ietf.org/html/rfc3164. The fields are
+	// appended to the message as key=value pairs.
+	RFC3164
+)
+
+const (
+	// DefaultStructuredDataID is the SD-ID of the structured data element holding the fields.
+	// 32473 is the enterprise number reserved for the documentation by RFC 5612.
+	DefaultStructuredDataID = "logrus@32473"
+
+	nilValue = "-"
+
+	rfc5424TimeFormat = "2006-01-02T15:04:05.000000Z07:00"
+	rfc3164TimeFormat = "Jan _2 15:04:05"
+)
+
+// Formatter formats the entries as syslog messages, without any transport framing.
+type Formatter struct {
+	// Protocol is the message format. The default is RFC5424.
+	Protocol Protocol
+
+	// Facility of the messages. The default is User: since the applications can't use Kern,
+	// the zero value is replaced by User.
+	Facility Facility
+
+	// AppName identifies the application. The default is the name of the executable.
+	AppName string
+
+	// Hostname of the machine. The default is the value returned by os.Hostname.
+	Hostname string
+
+	// ProcID is the process identifier. The default is the pid.
+	ProcID string
+
+	// MsgID identifies the type of the messages (RFC 5424 only). The default is empty.
+	MsgID string
+
+	// StructuredDataID is the SD-ID of the element holding the fields (RFC 5424 only). The
+	// default is DefaultStructuredDataID.
+	StructuredDataID string
+
+	once      sync.Once
+	hostname  string
+	appName   string
+	procID    string
+	msgID     string
+	sdID      string
+	tag       string
+	tagSuffix string
+}
+
+func (f *Formatter) init() {
+	f.hostname = f.Hostname
+	if f.hostname == "" {
+		f.hostname, _ = os.Hostname()
+	}
+	f.appName = f.AppName
+	if f.appName == "" {
+		f.appName = filepath.Base(os.Args[0])
+	}
+	f.procID = f.ProcID
+	if f.procID == "" {
+		f.procID = strconv.Itoa(os.Getpid())
+	}
+	f.sdID = f.StructuredDataID
+	if f.sdID == "" {
+		f.sdID = DefaultStructuredDataID
+	}
+
+	f.hostname = headerField(f.hostname, 255)
+	f.appName = headerField(f.appName, 48)
+	f.procID = headerField(f.procID, 128)
+	f.msgID = headerField(f.MsgID, 32)
+	f.sdID = sdName(f.sdID)
+
+	// The RFC 3164 tag is terminated by any non alphanumeric character, which makes the
+	// pid and the colon the natural delimiters
+	f.tag = sanitize(f.appName, 32, func(c byte) bool { return c != ':' && c != '[' })
+	f.tagSuffix = "[" + f.procID + "]: "
+}
+
+// Format renders a single log entry as a syslog message.
+func (f *Formatter) Format(entry *logrus.Entry) ([]byte, error) {
+	f.once.Do(f.init)
+	facility := f.Facility
+	if facility == Kern {
+		facility = User
+	}
+	priority := int(facility)*8 + int(SeverityOf(entry.Level))
+	keys, values := entryFields(entry)
+
+	b := make([]byte, 0, 256)
+	b = append(b, '<')
+	b = strconv.AppendInt(b, int64(priority), 10)
+	b = append(b, '>')
+
+	if f.Protocol == RFC3164 {
+		b = entry.Time.AppendFormat(b, rfc3164TimeFormat)
+		b = append(b, ' ')
+		b = append(b, f.hostname...)
+		b = append(b, ' ')
+		b = append(b, f.tag...)
+		b = append(b, f.tagSuffix...)
+		b = append(b, entry.Message...)
+		for _, key := range keys {
+			b = append(b, ' ')
+			b = append(b, key...)
+			b = append(b, '=')
+			b = appendPlainValue(b, values[key])
+		}
+		return b, nil
+	}
+
+	b = append(b, "1 "...)
+	b = entry.Time.AppendFormat(b, rfc5424TimeFormat)
+	b = append(b, ' ')
+	b = append(b, f.hostname...)
+	b = append(b, ' ')
+	b = append(b, f.appName...)
+	b = append(b, ' ')
+	b = append(b, f.procID...)
+	b = append(b, ' ')
+	b = append(b, f.msgID...)
+	b = append(b, ' ')
+	if len(keys) == 0 {
+		b = append(b, nilValue...)
+	} else {
+		b = append(b, '[')
+		b = append(b, f.sdID...)
+		for _, key := range keys {
+			b = append(b, ' ')
+			b = append(b, sdName(key)...)
+			b = append(b, '=', '"')
+			b = appendSDValue(b, values[key])
+			b = append(b, '"')
+		}
+		b = append(b, ']')
+	}
+	if entry.Message != "" {
+		b = append(b, ' ')
+		b = append(b, entry.Message...)
+	}
+	return b, nil
+}
+
+// entryFields returns the sorted keys and the values of the fields, the typed fields taking
+// precedence over Data
+func entryFields(entry *logrus.Entry) ([]string, map[string]string) {
+	values := make(map[string]string, len(entry.Data)+len(entry.Typed))
+	for k, v := range entry.Data {
+		values[k] = valueString(v)
+	}
+	for _, field := range entry.Typed {
+		if field.Type != logrus.UnknownType {
+			values[field.Key] = valueString(field.Value())
+		}
+	}
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys, values
+}
+
+func valueString(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case error:
+		return v.Error()
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	}
+	return fmt.Sprint(value)
+}
+
+// headerField replaces the characters which are not printable US-ASCII and truncates the value,
+// or returns the NILVALUE if it's empty
+func headerField(value string, max int) string {
+	if value == "" {
+		return nilValue
+	}
+	return sanitize(value, max, func(byte) bool { return true })
+}
+
+// sdName makes a valid SD-NAME, used for the SD-ID and the PARAM-NAMEs
+func sdName(name string) string {
+	if name == "" {
+		return "_"
+	}
+	return sanitize(name, 32, func(c byte) bool { return c != '=' && c != ']' && c != '"' })
+}
+
+// sanitize replaces the characters which are not printable US-ASCII, or rejected by valid, with
+// '_' and truncates the value to max bytes
+func sanitize(value string, max int, valid func(c byte) bool) string {
+	if len(value) > max {
+		value = value[:max]
+	}
+	for i := 0; i < len(value); i++ {
+		if c := value[i]; c < 33 || c > 126 || !valid(c) {
+			b := []byte(value)
+			for j := i; j < len(b); j++ {
+				if c := b[j]; c < 33 || c > 126 || !valid(c) {
+					b[j] = '_'
+				}
+			}
+			return string(b)
+		}
+	}
+	return value
+}
+
+// appendSDValue escapes the characters which have to be escaped in a PARAM-VALUE
+func appendSDValue(b []byte, value string) []byte {
+	for i := 0; i < len(value); i++ {
+		switch c := value[i]; c {
+		case '"', '\\', ']':
+			b = append(b, '\\', c)
+		default:
+			b = append(b, c)
+		}
+	}
+	return b
+}
+
+// appendPlainValue quotes the values which contain spaces, quotes or control characters
+func appendPlainValue(b []byte, value string) []byte {
+	for i := 0; i < len(value); i++ {
+		if c := value[i]; c <= ' ' || c == '"' || c == '=' || c == 0x7f {
+			return strconv.AppendQuote(b, value)
+		}
+	}
+	return append(b, value...)
+}
@@ -0,0 +1,260 @@
+package syslog
+
+import (
+	"bufio"
+	"errors"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xitonix/logrus"
+)
+
+var entryTime = time.Date(2018, 3, 8, 10, 30, 0, 123456789, time.UTC)
+
+func newEntry(level logrus.Level, message string, fields logrus.Fields) *logrus.Entry {
+	entry := logrus.NewEntryWithFields(logrus.New(logrus.DebugLevel), fields)
+	entry.Level = level
+	entry.Message = message
+	entry.Time = entryTime
+	return entry
+}
+
+func TestRFC5424(t *testing.T) {
+	f := &Formatter{Facility: Local0, AppName: "my app", Hostname: "host", ProcID: "42", MsgID: "login"}
+
+	entry := newEntry(logrus.WarnLevel, "access denied", logrus.Fields{
+		"user":   "wal\"rus]",
+		"count":  3,
+		"err":    errors.New("failure"),
+		"bad=ke": "value",
+	})
+	entry.Typed = []logrus.Field{logrus.Bool("typed", true), logrus.String("count", "typed wins")}
+
+	b, err := f.Format(entry)
+	require.NoError(t, err)
+	assert.Equal(t, `<132>1 2018-03-08T10:30:00.123456Z host my_app 42 login `+
+		`[logrus@32473 bad_ke="value" count="typed wins" err="failure" typed="true" user="wal\"rus\]"] access denied`, string(b))
+}
+
+func TestRFC5424WithoutFields(t *testing.T) {
+	f := &Formatter{Hostname: "host", AppName: "app", ProcID: "1", StructuredDataID: "custom@1"}
+
+	b, err := f.Format(newEntry(logrus.InfoLevel, "hello", nil))
+	require.NoError(t, err)
+	assert.Equal(t, "<14>1 2018-03-08T10:30:00.123456Z host app 1 - - hello", string(b))
+
+	b, err = f.Format(newEntry(logrus.InfoLevel, "hello", logrus.Fields{"k": "v"}))
+	require.NoError(t, err)
+	assert.Equal(t, `<14>1 2018-03-08T10:30:00.123456Z host app 1 - [custom@1 k="v"] hello`, string(b))
+}
+
+func TestRFC3164(t *testing.T) {
+	f := &Formatter{Protocol: RFC3164, Facility: Daemon, AppName: "app", Hostname: "host", ProcID: "42"}
+
+	b, err := f.Format(newEntry(logrus.ErrorLevel, "disk full", logrus.Fields{"path": "/var", "note": "two words"}))
+	require.NoError(t, err)
+	assert.Equal(t, `<27>Mar  8 10:30:00 host app[42]: disk full note="two words" path=/var`, string(b))
+}
+
+func TestFormatterDefaults(t *testing.T) {
+	f := &Formatter{}
+	b, err := f.Format(newEntry(logrus.InfoLevel, "hello", nil))
+	require.NoError(t, err)
+
+	hostname, _ := os.Hostname()
+	parts := strings.SplitN(string(b), " ", 7)
+	require.Len(t, parts, 7)
+	assert.Equal(t, "<14>1", parts[0])
+	assert.Equal(t, headerField(hostname, 255), parts[2])
+	assert.Equal(t, headerField(filepath.Base(os.Args[0]), 48), parts[3])
+	assert.Equal(t, strconv.Itoa(os.Getpid()), parts[4])
+}
+
+// noticeLevel is registered between the warning and the info levels
+var noticeLevel = logrus.MustRegisterLevel("notice", logrus.WarnLevel)
+
+func TestSeverityOf(t *testing.T) {
+	assert.Equal(t, Alert, SeverityOf(logrus.PanicLevel))
+	assert.Equal(t, Critical, SeverityOf(logrus.FatalLevel))
+	assert.Equal(t, Error, SeverityOf(logrus.ErrorLevel))
+	assert.Equal(t, Warning, SeverityOf(logrus.WarnLevel))
+	assert.Equal(t, Informational, SeverityOf(logrus.InfoLevel))
+	assert.Equal(t, Debug, SeverityOf(logrus.DebugLevel))
+	assert.Equal(t, Debug, SeverityOf(logrus.TraceLevel))
+	assert.Equal(t, Notice, SeverityOf(noticeLevel))
+}
+
+func TestUDPSink(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer conn.Close()
+
+	sink, err := NewSink("udp", conn.LocalAddr().String(), logrus.InfoLevel, &Formatter{Hostname: "host", AppName: "app", ProcID: "1"})
+	require.NoError(t, err)
+	defer sink.Out.(*Writer).Close()
+
+	logger := logrus.New(logrus.InfoLevel)
+	logger.SetOutput(ioutil.Discard)
+	logger.AddSink(sink)
+	logger.Debug("not sent")
+	logger.AsInfo().WithField("key", "value").Write("hello")
+
+	buf := make([]byte, 1024)
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	n, _, err := conn.ReadFrom(buf)
+	require.NoError(t, err)
+	message := string(buf[:n])
+	assert.True(t, strings.HasPrefix(message, "<14>1 "), message)
+	assert.True(t, strings.HasSuffix(message, ` host app 1 - [logrus@32473 key="value"] hello`), message)
+}
+
+func TestUnixgramWriter(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("unix sockets are not supported")
+	}
+	dir, err := ioutil.TempDir("", "syslog")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "log")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	require.NoError(t, err)
+	defer conn.Close()
+
+	w, err := Dial("", path)
+	require.NoError(t, err)
+	defer w.Close()
+
+	_, err = w.Write([]byte("<14>1 - - - - - message\n"))
+	require.NoError(t, err)
+
+	buf := make([]byte, 1024)
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	n, err := conn.Read(buf)
+	require.NoError(t, err)
+	assert.Equal(t, "<14>1 - - - - - message", string(buf[:n]))
+}
+
+func TestUnixStreamWriterEndsTheMessagesWithANewline(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("unix sockets are not supported")
+	}
+	dir, err := ioutil.TempDir("", "syslog")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "log")
+	listener, err := net.Listen("unix", path)
+	require.NoError(t, err)
+	defer listener.Close()
+
+	// The local sockets fall back to the stream ones
+	w, err := Dial("", path)
+	require.NoError(t, err)
+	defer w.Close()
+
+	conn, err := listener.Accept()
+	require.NoError(t, err)
+	defer conn.Close()
+
+	_, err = w.Write([]byte("<14>1 - - - - - first\n"))
+	require.NoError(t, err)
+	_, err = w.Write([]byte("<14>1 - - - - - second"))
+	require.NoError(t, err)
+
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	r := bufio.NewReader(conn)
+	for _, expected := range []string{"<14>1 - - - - - first\n", "<14>1 - - - - - second\n"} {
+		line, err := r.ReadString('\n')
+		require.NoError(t, err)
+		assert.Equal(t, expected, line)
+	}
+}
+
+// readFrame reads an octet counted message
+func readFrame(t *testing.T, r *bufio.Reader) string {
+	length, err := r.ReadString(' ')
+	require.NoError(t, err)
+	n, err := strconv.Atoi(strings.TrimSpace(length))
+	require.NoError(t, err)
+	buf := make([]byte, n)
+	_, err = r.Read(buf)
+	require.NoError(t, err)
+	return string(buf)
+}
+
+func TestTCPWriterFramingAndReconnect(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	conns := make(chan net.Conn, 2)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			conns <- conn
+		}
+	}()
+
+	w, err := Dial("tcp", listener.Addr().String())
+	require.NoError(t, err)
+	defer w.Close()
+
+	first := <-conns
+	_, err = w.Write([]byte("first message\n"))
+	require.NoError(t, err)
+	_, err = w.Write([]byte("with\nnewline"))
+	require.NoError(t, err)
+
+	require.NoError(t, first.SetReadDeadline(time.Now().Add(5*time.Second)))
+	r := bufio.NewReader(first)
+	assert.Equal(t, "first message", readFrame(t, r))
+	assert.Equal(t, "with\nnewline", readFrame(t, r))
+
+	// The server goes away: the writes eventually fail and the writer reconnects
+	first.Close()
+	var second net.Conn
+	for second == nil {
+		_, _ = w.Write([]byte("lost"))
+		select {
+		case second = <-conns:
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+	defer second.Close()
+
+	_, err = w.Write([]byte("after reconnect"))
+	require.NoError(t, err)
+	require.NoError(t, second.SetReadDeadline(time.Now().Add(5*time.Second)))
+	r = bufio.NewReader(second)
+	for {
+		if message := readFrame(t, r); message != "lost" {
+			assert.Equal(t, "after reconnect", message)
+			break
+		}
+	}
+}
+
+func TestClosedWriter(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer conn.Close()
+
+	w, err := Dial("udp", conn.LocalAddr().String())
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	_, err = w.Write([]byte("message"))
+	assert.Equal(t, errClosed, err)
+}
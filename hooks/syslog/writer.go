@@ -0,0 +1,187 @@
+package syslog
+
+import (
+	"errors"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/xitonix/logrus"
+)
+
+const (
+	dialTimeout  = 5 * time.Second
+	writeTimeout = 5 * time.Second
+)
+
+// localSockets are the usual paths of the local syslog daemon's socket
+var localSockets = []string{"/dev/log", "/var/run/syslog", "/var/run/log"}
+
+var errClosed = errors.New("syslog: writer is closed")
+
+// Writer sends every Write as one syslog message. The datagram transports (unixgram and udp)
+// send a message per datagram. TCP frames the messages with the octet counting of RFC 6587, and
+// the local stream sockets (unix) end them with a newline, like the log/syslog package does. A
+// trailing newline is removed from the messages before framing them.
+//
+// When a write fails, the Writer reconnects and retries once. If the server is still
+// unreachable, the error is returned and the next write tries to reconnect again. It's safe for
+// concurrent use.
+type Writer struct {
+	network string
+	address string
+
+	mu      sync.Mutex
+	conn    net.Conn
+	framing framing
+	closed  bool
+	buf     []byte
+}
+
+// Dial connects to the syslog server. The network is one of "unix", "unixgram", "udp" and
+// "tcp" (or their IPv4 and IPv6 variants). If the network is empty, the address is the path of
+// a local socket, either datagram or stream, and an empty address looks for the local syslog
+// daemon at the usual paths ("/dev/log", "/var/run/syslog" and "/var/run/log").
+func Dial(network, address string) (*Writer, error) {
+	w := &Writer{network: network, address: address}
+	if err := w.connect(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// connect dials the server. It must be called with w.mu held.
+func (w *Writer) connect() error {
+	var conn net.Conn
+	var err error
+	if w.network == "" {
+		conn, err = dialLocal(w.address)
+	} else {
+		conn, err = net.DialTimeout(w.network, w.address, dialTimeout)
+	}
+	if err != nil {
+		return err
+	}
+
+	w.conn = conn
+	switch conn.LocalAddr().Network() {
+	case "tcp", "tcp4", "tcp6":
+		w.framing = octetCounting
+	case "unix":
+		w.framing = newlineTerminated
+	default:
+		w.framing = datagram
+	}
+	return nil
+}
+
+// framing tells how the messages are delimited on the connection
+type framing int
+
+const (
+	// datagram sends a message per datagram, without framing
+	datagram framing = iota
+	// octetCounting prefixes the messages with their length and a space (RFC 6587)
+	octetCounting
+	// newlineTerminated ends the messages with a newline, which the local daemons expect on
+	// their stream sockets
+	newlineTerminated
+)
+
+func dialLocal(address string) (net.Conn, error) {
+	paths := localSockets
+	if address != "" {
+		paths = []string{address}
+	}
+	var err error
+	for _, network := range []string{"unixgram", "unix"} {
+		for _, path := range paths {
+			var conn net.Conn
+			if conn, err = net.DialTimeout(network, path, dialTimeout); err == nil {
+				return conn, nil
+			}
+		}
+	}
+	return nil, err
+}
+
+// Write sends p as one message.
+func (w *Writer) Write(p []byte) (int, error) {
+	message := p
+	if n := len(message); n > 0 && message[n-1] == '\n' {
+		message = message[:n-1]
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return 0, errClosed
+	}
+
+	if w.conn != nil {
+		if err := w.send(message); err == nil {
+			return len(p), nil
+		}
+		w.conn.Close()
+		w.conn = nil
+	}
+
+	if err := w.connect(); err != nil {
+		return 0, err
+	}
+	if err := w.send(message); err != nil {
+		w.conn.Close()
+		w.conn = nil
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// send writes the framed message. It must be called with w.mu held.
+func (w *Writer) send(message []byte) error {
+	if err := w.conn.SetWriteDeadline(time.Now().Add(writeTimeout)); err != nil {
+		return err
+	}
+	switch w.framing {
+	case octetCounting:
+		w.buf = strconv.AppendInt(w.buf[:0], int64(len(message)), 10)
+		w.buf = append(w.buf, ' ')
+		w.buf = append(w.buf, message...)
+	case newlineTerminated:
+		w.buf = append(append(w.buf[:0], message...), '\n')
+	default:
+		_, err := w.conn.Write(message)
+		return err
+	}
+	_, err := w.conn.Write(w.buf)
+	return err
+}
+
+// Close closes the connection. The writes fail afterwards.
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.closed = true
+	if w.conn == nil {
+		return nil
+	}
+	err := w.conn.Close()
+	w.conn = nil
+	return err
+}
+
+// NewSink dials the syslog server (see Dial) and returns a sink sending the entries at or above
+// the level, formatted by the formatter. A nil formatter writes RFC 5424 messages with the
+// default settings. The Out of the sink is the *Writer, which should be closed once the Logger
+// is no longer used.
+func NewSink(network, address string, level logrus.Level, formatter *Formatter) (logrus.Sink, error) {
+	w, err := Dial(network, address)
+	if err != nil {
+		return logrus.Sink{}, err
+	}
+	if formatter == nil {
+		formatter = &Formatter{}
+	}
+	return logrus.Sink{Formatter: formatter, Out: w, Level: level}, nil
+}
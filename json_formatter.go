@@ -1,10 +1,25 @@
 package logrus
 
 import (
+	"bytes"
+	"encoding"
 	"encoding/json"
 	"fmt"
+	"math"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
 )
 
+// hex is used to escape the control characters in the JSON strings
+const hex = "0123456789abcdef"
+
+// defaultJSONIndent is the indentation used by PrettyPrint when Indent is not set
+const defaultJSONIndent = "  "
+
 type fieldKey string
 
 // FieldMap allows customization of the key names for default fields.
@@ -36,37 +51,387 @@ type JSONFormatter struct {
 	//    },
 	// }
 	FieldMap FieldMap
+
+	// CallerPrettyfier can be set by the user to modify the content of the
+	// function and file keys in the json data when the Logger is reporting the
+	// caller. If either of the returned values is the empty string, the
+	// corresponding key will be removed from the json fields.
+	CallerPrettyfier func(*runtime.Frame) (function string, file string)
+
+	// DataKey nests all the fields under an object with this key, e.g. "fields", so they never
+	// collide with the default fields and don't have to be prefixed.
+	DataKey string
+
+	// ExpandDottedKeys expands the keys with dots into nested objects, e.g. the field `http.status`
+	// is written as `"http":{"status":...}`. A dotted key which would be nested in a field with a
+	// plain key, or which has an empty part, is written as is.
+	ExpandDottedKeys bool
+
+	// PrettyPrint indents the JSON output.
+	PrettyPrint bool
+
+	// Indent is the indentation used by PrettyPrint. The default is two spaces.
+	Indent string
+
+	// StructuredErrors writes the errors as objects instead of their message, with the "msg",
+	// the "type", the "stack" trace if the error carries one (see ErrorWithStack), and the
+	// "cause" wrapped by the error, or the "causes" joined in it, written the same way.
+	StructuredErrors bool
 }
 
-// Format renders a single log entry
+// Format renders a single log entry. The time, level and message are written first, followed
+// by the caller, if reported, and the fields sorted by key.
 func (f *JSONFormatter) Format(entry *Entry) ([]byte, error) {
-	data := make(Fields, len(entry.Data)+3)
-	for k, v := range entry.Data {
-		switch v := v.(type) {
-		case error:
-			// Otherwise errors are ignored by `encoding/json`
-			// https://github.com/sirupsen/logrus/issues/137
-			data[k] = v.Error()
-		default:
-			data[k] = v
+	return formatCopy(f, entry)
+}
+
+func (f *JSONFormatter) appendFormat(b []byte, entry *Entry) ([]byte, error) {
+	enc := newFormatEncoder()
+	defer enc.release()
+
+	start := len(b)
+	b = append(b, '{')
+	if !f.DisableTimestamp && entry.hasTime() {
+		timestampFormat := f.TimestampFormat
+		if timestampFormat == "" {
+			timestampFormat = defaultTimestampFormat
 		}
+		b = appendJSONKey(b, f.FieldMap.resolve(timeKey))
+		b = appendJSONTime(b, entry.Time, timestampFormat)
 	}
-	prefixFieldClashes(data)
+	b = appendJSONKey(b, f.FieldMap.resolve(levelKey))
+	b = appendJSONString(b, entry.Level.String())
+	b = appendJSONKey(b, f.FieldMap.resolve(messageKey))
+	b = appendJSONString(b, entry.Message)
 
-	timestampFormat := f.TimestampFormat
-	if timestampFormat == "" {
-		timestampFormat = defaultTimestampFormat
+	if entry.Caller != nil {
+		funcVal, fileVal := callerFields(entry.Caller, f.CallerPrettyfier)
+		if funcVal != "" {
+			b = appendJSONKey(b, f.FieldMap.resolve(funcKey))
+			b = appendJSONString(b, funcVal)
+		}
+		if fileVal != "" {
+			b = appendJSONKey(b, f.FieldMap.resolve(fileKey))
+			b = appendJSONString(b, fileVal)
+		}
 	}
 
-	if !f.DisableTimestamp {
-		data[f.FieldMap.resolve(timeKey)] = entry.Time.Format(timestampFormat)
+	enc.fields = appendFieldRefs(enc.fields[:0], entry, f.DataKey == "")
+	if f.DataKey != "" {
+		// The nested fields can't clash with the default fields
+		for i := range enc.fields {
+			enc.fields[i].name = enc.fields[i].key
+		}
+		b = appendJSONKey(b, f.DataKey)
+		b = append(b, '{')
 	}
-	data[f.FieldMap.resolve(messageKey)] = entry.Message
-	data[f.FieldMap.resolve(levelKey)] = entry.Level.String()
+	sort.Sort(enc)
 
-	serialized, err := json.Marshal(data)
+	var err error
+	if f.ExpandDottedKeys {
+		b, err = appendJSONTree(b, newJSONTree(enc.fields, f.DataKey == "", entry.Caller != nil), entry, enc.fields, f.StructuredErrors)
+	} else {
+		for _, field := range enc.fields {
+			b = appendJSONKey(b, field.name)
+			if b, err = appendJSONFieldRef(b, entry, field, f.StructuredErrors); err != nil {
+				break
+			}
+		}
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal fields to JSON, %v", err)
+		return b[:start], fmt.Errorf("failed to marshal fields to JSON, %v", err)
+	}
+	if f.DataKey != "" {
+		b = append(b, '}')
+	}
+	b = append(b, '}')
+
+	if f.PrettyPrint {
+		indent := f.Indent
+		if indent == "" {
+			indent = defaultJSONIndent
+		}
+		var out bytes.Buffer
+		if err := json.Indent(&out, b[start:], "", indent); err != nil {
+			return b[:start], fmt.Errorf("failed to indent JSON, %v", err)
+		}
+		b = append(b[:start], out.Bytes()...)
+	}
+	return append(b, '\n'), nil
+}
+
+func appendJSONFieldRef(b []byte, entry *Entry, field fieldRef, structuredErrors bool) ([]byte, error) {
+	if structuredErrors {
+		if err, ok := fieldError(entry, field); ok {
+			return appendJSONError(b, newErrorNode(err, 0)), nil
+		}
+	}
+	if field.typed < 0 {
+		return appendJSONValue(b, entry.Data[field.key])
+	}
+	return appendJSONField(b, entry.Typed[field.typed])
+}
+
+// jsonTree is an object built from the dotted keys when ExpandDottedKeys is set. The leaves
+// refer to the fields by their index.
+type jsonTree struct {
+	name     string
+	field    int
+	children []*jsonTree
+}
+
+func (t *jsonTree) child(name string) *jsonTree {
+	for _, c := range t.children {
+		if c.name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// newJSONTree builds the tree of the sorted fields. If the fields are at the top level, the first
+// part of their keys is prefixed when it clashes with the default fields.
+func newJSONTree(fields fieldRefs, topLevel bool, reportCaller bool) *jsonTree {
+	root := &jsonTree{field: -1}
+	for i, field := range fields {
+		parts := strings.Split(field.key, ".")
+		if topLevel {
+			parts[0] = prefixedKey(parts[0], reportCaller)
+		}
+		if !root.insert(parts, i) {
+			root.children = append(root.children, &jsonTree{name: field.name, field: i})
+		}
+	}
+	return root
+}
+
+// insert adds the field at the path and returns false if it can't be expanded
+func (t *jsonTree) insert(path []string, field int) bool {
+	node := t
+	for i, name := range path {
+		if name == "" && len(path) > 1 {
+			return false
+		}
+		child := node.child(name)
+		if i == len(path)-1 {
+			if child != nil {
+				return false
+			}
+			node.children = append(node.children, &jsonTree{name: name, field: field})
+			return true
+		}
+		if child == nil {
+			child = &jsonTree{name: name, field: -1}
+			node.children = append(node.children, child)
+		} else if child.field >= 0 {
+			return false
+		}
+		node = child
+	}
+	return false
+}
+
+// appendJSONTree appends the members of the object, without the braces
+func appendJSONTree(b []byte, t *jsonTree, entry *Entry, fields fieldRefs, structuredErrors bool) ([]byte, error) {
+	var err error
+	for _, c := range t.children {
+		b = appendJSONKey(b, c.name)
+		if c.field >= 0 {
+			b, err = appendJSONFieldRef(b, entry, fields[c.field], structuredErrors)
+		} else {
+			b = append(b, '{')
+			b, err = appendJSONTree(b, c, entry, fields, structuredErrors)
+			b = append(b, '}')
+		}
+		if err != nil {
+			return b, err
+		}
+	}
+	return b, nil
+}
+
+// appendJSONKey appends the quoted key and the colon, preceded by a comma unless it's the first key
+func appendJSONKey(b []byte, key string) []byte {
+	if len(b) > 0 && b[len(b)-1] != '{' {
+		b = append(b, ',')
+	}
+	b = appendJSONString(b, key)
+	return append(b, ':')
+}
+
+// appendJSONTime appends the quoted time, escaping it only if the layout requires it
+func appendJSONTime(b []byte, t time.Time, layout string) []byte {
+	start := len(b)
+	b = append(b, '"')
+	b = t.AppendFormat(b, layout)
+	for _, c := range b[start+1:] {
+		if c < 0x20 || c >= utf8.RuneSelf || c == '"' || c == '\\' || c == '<' || c == '>' || c == '&' {
+			formatted := string(b[start+1:])
+			return appendJSONString(b[:start], formatted)
+		}
+	}
+	return append(b, '"')
+}
+
+// appendJSONValue appends the JSON encoding of a value of Data. The common types are encoded
+// directly, encoding/json is only used for the other ones.
+func appendJSONValue(b []byte, value interface{}) ([]byte, error) {
+	switch v := value.(type) {
+	case nil:
+		return append(b, "null"...), nil
+	case string:
+		return appendJSONString(b, v), nil
+	case bool:
+		return strconv.AppendBool(b, v), nil
+	case int:
+		return strconv.AppendInt(b, int64(v), 10), nil
+	case int8:
+		return strconv.AppendInt(b, int64(v), 10), nil
+	case int16:
+		return strconv.AppendInt(b, int64(v), 10), nil
+	case int32:
+		return strconv.AppendInt(b, int64(v), 10), nil
+	case int64:
+		return strconv.AppendInt(b, v, 10), nil
+	case uint:
+		return strconv.AppendUint(b, uint64(v), 10), nil
+	case uint8:
+		return strconv.AppendUint(b, uint64(v), 10), nil
+	case uint16:
+		return strconv.AppendUint(b, uint64(v), 10), nil
+	case uint32:
+		return strconv.AppendUint(b, uint64(v), 10), nil
+	case uint64:
+		return strconv.AppendUint(b, v, 10), nil
+	case float32:
+		return appendJSONFloat(b, float64(v), 32), nil
+	case float64:
+		return appendJSONFloat(b, v, 64), nil
+	case time.Duration:
+		return strconv.AppendInt(b, int64(v), 10), nil
+	case time.Time:
+		return appendJSONTime(b, v, time.RFC3339Nano), nil
+	case error:
+		// Otherwise errors are ignored by `encoding/json`
+		// https://github.com/sirupsen/logrus/issues/137
+		return appendJSONString(b, v.Error()), nil
+	case json.Marshaler, encoding.TextMarshaler:
+		return appendMarshaledJSON(b, v)
+	case fmt.Stringer:
+		return appendJSONString(b, v.String()), nil
+	}
+	return appendMarshaledJSON(b, value)
+}
+
+func appendMarshaledJSON(b []byte, value interface{}) ([]byte, error) {
+	serialized, err := json.Marshal(value)
+	if err != nil {
+		return b, err
+	}
+	return append(b, serialized...), nil
+}
+
+// appendJSONField appends the JSON encoding of the value of a typed field, without reflection
+// unless the field was created with Any.
+func appendJSONField(b []byte, field Field) ([]byte, error) {
+	switch field.Type {
+	case StringType:
+		return appendJSONString(b, field.String), nil
+	case Int64Type, DurationType:
+		return strconv.AppendInt(b, field.Integer, 10), nil
+	case Float64Type:
+		return appendJSONFloat(b, field.float64(), 64), nil
+	case BoolType:
+		return strconv.AppendBool(b, field.Integer == 1), nil
+	case TimeType:
+		b = append(b, '"')
+		b = field.time().AppendFormat(b, time.RFC3339Nano)
+		return append(b, '"'), nil
+	case ErrorType:
+		if err, ok := field.Interface.(error); ok {
+			return appendJSONString(b, err.Error()), nil
+		}
+		return append(b, "null"...), nil
+	}
+
+	return appendJSONValue(b, field.Interface)
+}
+
+// appendJSONFloat formats the number like encoding/json. NaN and infinities, which are not
+// valid JSON numbers, are encoded as strings.
+func appendJSONFloat(b []byte, f float64, bits int) []byte {
+	switch {
+	case math.IsNaN(f):
+		return append(b, `"NaN"`...)
+	case math.IsInf(f, 1):
+		return append(b, `"+Inf"`...)
+	case math.IsInf(f, -1):
+		return append(b, `"-Inf"`...)
+	}
+
+	format := byte('f')
+	if abs := math.Abs(f); abs != 0 {
+		if bits == 64 && (abs < 1e-6 || abs >= 1e21) || bits == 32 && (float32(abs) < 1e-6 || float32(abs) >= 1e21) {
+			format = 'e'
+		}
+	}
+	b = strconv.AppendFloat(b, f, format, -1, bits)
+	if format == 'e' {
+		// clean up e-09 to e-9
+		n := len(b)
+		if n >= 4 && b[n-4] == 'e' && b[n-3] == '-' && b[n-2] == '0' {
+			b[n-2] = b[n-1]
+			b = b[:n-1]
+		}
+	}
+	return b
+}
+
+// appendJSONString appends the quoted string, escaped the same way as encoding/json does
+func appendJSONString(b []byte, s string) []byte {
+	b = append(b, '"')
+	start := 0
+	for i := 0; i < len(s); {
+		if c := s[i]; c < utf8.RuneSelf {
+			if c >= 0x20 && c != '"' && c != '\\' && c != '<' && c != '>' && c != '&' {
+				i++
+				continue
+			}
+			b = append(b, s[start:i]...)
+			switch c {
+			case '"', '\\':
+				b = append(b, '\\', c)
+			case '\n':
+				b = append(b, '\\', 'n')
+			case '\r':
+				b = append(b, '\\', 'r')
+			case '\t':
+				b = append(b, '\\', 't')
+			default:
+				b = append(b, '\\', 'u', '0', '0', hex[c>>4], hex[c&0xF])
+			}
+			i++
+			start = i
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			b = append(b, s[start:i]...)
+			b = append(b, "\ufffd"...)
+			i += size
+			start = i
+			continue
+		}
+		// U+2028 and U+2029 are valid JSON but break JavaScript
+		if r == '\u2028' || r == '\u2029' {
+			b = append(b, s[start:i]...)
+			b = append(b, '\\', 'u', '2', '0', '2', hex[r&0xF])
+			i += size
+			start = i
+			continue
+		}
+		i += size
 	}
-	return append(serialized, '\n'), nil
+	b = append(b, s[start:]...)
+	return append(b, '"')
 }
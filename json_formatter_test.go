@@ -3,8 +3,14 @@ package logrus
 import (
 	"encoding/json"
 	"errors"
+	"math"
+	"net"
 	"strings"
 	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestErrorNotLost(t *testing.T) {
@@ -197,3 +203,156 @@ func TestJSONEnableTimestamp(t *testing.T) {
 		t.Error("Timestamp not present", s)
 	}
 }
+
+func TestJSONFixedKeysFirstAndSortedFields(t *testing.T) {
+	formatter := &JSONFormatter{DisableTimestamp: true}
+
+	entry := WithFields(Fields{"zulu": 1, "alpha": "a", "mike": true})
+	entry.Message = "hello"
+	entry.Level = WarnLevel
+
+	b, err := formatter.Format(entry)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"level":"warning","msg":"hello","alpha":"a","mike":true,"zulu":1}`+"\n", string(b))
+}
+
+type jsonStringer struct {
+	Name string
+}
+
+func (s jsonStringer) String() string {
+	return "stringer " + s.Name
+}
+
+type jsonMarshaler struct{}
+
+func (jsonMarshaler) MarshalJSON() ([]byte, error) {
+	return []byte(`{"custom":true}`), nil
+}
+
+func TestJSONValueEncoding(t *testing.T) {
+	now := time.Date(2018, 3, 8, 10, 30, 0, 5, time.UTC)
+	values := []interface{}{
+		nil, "string <&>", true, int(-1), int8(-8), int16(16), int32(-32), int64(math.MinInt64),
+		uint(1), uint8(8), uint16(16), uint32(32), uint64(math.MaxUint64),
+		float32(0.1), float32(1e-7), float64(0.1), 1e21, time.Second, now,
+		[]string{"a", "b"}, map[string]int{"a": 1}, struct{ A int }{1}, jsonMarshaler{}, net.IPv4(127, 0, 0, 1),
+	}
+
+	for _, v := range values {
+		expected, err := json.Marshal(v)
+		require.NoError(t, err)
+		b, err := appendJSONValue(nil, v)
+		assert.NoError(t, err)
+		assert.Equal(t, string(expected), string(b), "%T", v)
+	}
+}
+
+func TestJSONStringerAndErrorValues(t *testing.T) {
+	b, err := appendJSONValue(nil, jsonStringer{Name: "value"})
+	assert.NoError(t, err)
+	assert.Equal(t, `"stringer value"`, string(b))
+
+	b, err = appendJSONValue(nil, errors.New("wild walrus"))
+	assert.NoError(t, err)
+	assert.Equal(t, `"wild walrus"`, string(b))
+}
+
+func TestJSONUnsupportedValue(t *testing.T) {
+	formatter := &JSONFormatter{}
+
+	_, err := formatter.Format(WithField("channel", make(chan int)))
+	assert.Error(t, err)
+}
+
+func TestJSONTimestampFormatIsEscaped(t *testing.T) {
+	formatter := &JSONFormatter{TimestampFormat: `"2006"`}
+
+	b, err := formatter.Format(&Entry{Time: time.Date(2018, 3, 8, 0, 0, 0, 0, time.UTC)})
+	assert.NoError(t, err)
+
+	fields := make(map[string]interface{})
+	assert.NoError(t, json.Unmarshal(b, &fields))
+	assert.Equal(t, `"2018"`, fields[timeKey])
+}
+
+func TestJSONFormatterDoesNotReuseTheOutput(t *testing.T) {
+	formatter := &JSONFormatter{DisableTimestamp: true}
+
+	first, err := formatter.Format(&Entry{Message: "first"})
+	assert.NoError(t, err)
+	_, err = formatter.Format(&Entry{Message: "second"})
+	assert.NoError(t, err)
+
+	assert.Equal(t, `{"level":"panic","msg":"first"}`+"\n", string(first))
+}
+
+func TestJSONPrettyPrint(t *testing.T) {
+	entry := WithField("key", "value")
+	entry.Message = "hello"
+	entry.Level = InfoLevel
+
+	b, err := (&JSONFormatter{DisableTimestamp: true, PrettyPrint: true}).Format(entry)
+	assert.NoError(t, err)
+	assert.Equal(t, "{\n  \"level\": \"info\",\n  \"msg\": \"hello\",\n  \"key\": \"value\"\n}\n", string(b))
+
+	b, err = (&JSONFormatter{DisableTimestamp: true, PrettyPrint: true, Indent: "\t"}).Format(entry)
+	assert.NoError(t, err)
+	assert.Equal(t, "{\n\t\"level\": \"info\",\n\t\"msg\": \"hello\",\n\t\"key\": \"value\"\n}\n", string(b))
+}
+
+func TestJSONDataKey(t *testing.T) {
+	entry := WithFields(Fields{levelKey: "user level", "key": "value"}).With(String(messageKey, "user msg"))
+	entry.Message = "hello"
+	entry.Level = InfoLevel
+
+	b, err := (&JSONFormatter{DisableTimestamp: true, DataKey: "fields"}).Format(entry)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"level":"info","msg":"hello","fields":{"key":"value","level":"user level","msg":"user msg"}}`+"\n", string(b))
+}
+
+func TestJSONDataKeyWithoutFields(t *testing.T) {
+	b, err := (&JSONFormatter{DisableTimestamp: true, DataKey: "fields"}).Format(&Entry{Message: "hello", Level: InfoLevel})
+	assert.NoError(t, err)
+	assert.Equal(t, `{"level":"info","msg":"hello","fields":{}}`+"\n", string(b))
+}
+
+func TestJSONExpandDottedKeys(t *testing.T) {
+	entry := WithFields(Fields{
+		"http.status":         200,
+		"http.request.method": "GET",
+		"http.request.path":   "/",
+		"user":                "walrus",
+	})
+	entry.Message = "hello"
+	entry.Level = InfoLevel
+
+	b, err := (&JSONFormatter{DisableTimestamp: true, ExpandDottedKeys: true}).Format(entry)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"level":"info","msg":"hello","http":{"request":{"method":"GET","path":"/"},"status":200},"user":"walrus"}`+"\n", string(b))
+
+	b, err = (&JSONFormatter{DisableTimestamp: true, ExpandDottedKeys: true, DataKey: "fields"}).Format(entry)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"level":"info","msg":"hello","fields":{"http":{"request":{"method":"GET","path":"/"},"status":200},"user":"walrus"}}`+"\n", string(b))
+}
+
+func TestJSONExpandDottedKeysConflicts(t *testing.T) {
+	entry := WithFields(Fields{
+		"http":        "plain",
+		"http.status": 200,
+		"a..b":        1,
+		"level.name":  "user level",
+	})
+	entry.Level = InfoLevel
+
+	b, err := (&JSONFormatter{DisableTimestamp: true, ExpandDottedKeys: true}).Format(entry)
+	assert.NoError(t, err)
+
+	fields := make(map[string]interface{})
+	assert.NoError(t, json.Unmarshal(b, &fields))
+	assert.Equal(t, "plain", fields["http"])
+	assert.Equal(t, float64(200), fields["http.status"])
+	assert.Equal(t, float64(1), fields["a..b"])
+	assert.Equal(t, "info", fields[levelKey])
+	assert.Equal(t, map[string]interface{}{"name": "user level"}, fields["fields.level"])
+}
@@ -0,0 +1,239 @@
+package logrus
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"time"
+)
+
+// levelOverride tracks the temporary level set by SetLevelFor
+type levelOverride struct {
+	mux      sync.Mutex
+	timer    *time.Timer
+	previous Level
+	expires  time.Time
+}
+
+// cancel drops the pending restoration of the previous level
+func (o *levelOverride) cancel() {
+	o.mux.Lock()
+	defer o.mux.Unlock()
+	if o.timer != nil {
+		o.timer.Stop()
+		o.timer = nil
+	}
+}
+
+// expiry returns the time the previous level gets restored at, if the level is overridden
+func (o *levelOverride) expiry() (time.Time, bool) {
+	o.mux.Lock()
+	defer o.mux.Unlock()
+	return o.expires, o.timer != nil
+}
+
+// SetLevelFor sets the level of the Logger for the duration d, then restores the previous level.
+// Calling SetLevelFor again before d has elapsed extends the override, and the level from before
+// the first call is restored in the end. SetLevel cancels the restoration.
+func (logger *Logger) SetLevelFor(level Level, d time.Duration) {
+	o := &logger.override
+	o.mux.Lock()
+	defer o.mux.Unlock()
+	if o.timer == nil {
+		o.previous = logger.Level()
+	} else {
+		o.timer.Stop()
+	}
+	logger.storeLevel(level)
+
+	var timer *time.Timer
+	timer = time.AfterFunc(d, func() {
+		o.mux.Lock()
+		defer o.mux.Unlock()
+		// The override may have been cancelled or extended since the timer fired
+		if o.timer == timer {
+			logger.storeLevel(o.previous)
+			o.timer = nil
+		}
+	})
+	o.timer = timer
+	o.expires = time.Now().Add(d)
+}
+
+// ToggleLevelOnSignal switches the Logger to the level every time the process receives one of
+// the signals, and back to the previous level on the next one. For example, sending SIGUSR1 to a
+// live process turns the debug logs on and off without restarting it:
+//
+//	logger.ToggleLevelOnSignal(logrus.DebugLevel, syscall.SIGUSR1)
+//
+// SIGUSR1 is used if no signal is specified, except on windows where there is no default. The
+// returned function stops listening to the signals.
+func (logger *Logger) ToggleLevelOnSignal(level Level, signals ...os.Signal) (stop func()) {
+	if len(signals) == 0 {
+		signals = defaultToggleSignals
+	}
+	received := make(chan os.Signal, 1)
+	stopped := make(chan struct{})
+	if len(signals) > 0 {
+		signal.Notify(received, signals...)
+	}
+
+	go func() {
+		previous := logger.Level()
+		for {
+			select {
+			case <-received:
+				if current := logger.Level(); current != level {
+					previous = current
+					logger.SetLevel(level)
+				} else {
+					logger.SetLevel(previous)
+				}
+			case <-stopped:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			signal.Stop(received)
+			close(stopped)
+		})
+	}
+}
+
+// levelState is the JSON document served by the level handler
+type levelState struct {
+	Level      string            `json:"level"`
+	Expires    *time.Time        `json:"expires,omitempty"`
+	Components map[string]string `json:"components,omitempty"`
+}
+
+// levelRequest is the JSON document accepted by the level handler
+type levelRequest struct {
+	Logger   string `json:"logger"`
+	Level    string `json:"level"`
+	Duration string `json:"duration"`
+}
+
+// LevelHandler returns an http.Handler reading and changing the level of the Logger, so the
+// verbosity of a live process can be raised without restarting it:
+//
+// * GET returns the level, like {"level":"info"}. When the level is temporarily overridden, the
+// time the previous level gets restored at is returned as "expires", and the levels of the named
+// components (see SetComponentLevel) as "components".
+//
+// * PUT and POST set the level, parsed with ParseLevel, either from a JSON body like
+// {"level":"debug","duration":"10m"} or from the "level" and "duration" query or form
+// parameters. With a duration, the previous level is restored once it has elapsed (see
+// SetLevelFor). The new state is returned like for GET.
+//
+// * With a "logger" name, PUT and POST set the level of the component instead, like
+// {"logger":"db.pool","level":"debug"}. An empty level resets the component's level.
+//
+// The bodies larger than a few kilobytes are rejected. The handler doesn't authenticate the
+// requests: anyone reaching it can change the verbosity of the process, so it must be mounted
+// behind the authentication of the application, or on an internal listener.
+func (logger *Logger) LevelHandler() http.Handler {
+	return &levelHandler{logger: logger}
+}
+
+// maxLevelRequestSize is the largest body accepted by the level handler
+const maxLevelRequestSize = 4 << 10
+
+type levelHandler struct {
+	logger *Logger
+}
+
+func (h *levelHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut, http.MethodPost:
+		if err := h.setLevel(w, r); err != nil {
+			writeLevelJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+	default:
+		w.Header().Set("Allow", "GET, PUT, POST")
+		writeLevelJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+		return
+	}
+
+	state := levelState{Level: h.logger.Level().String()}
+	if expires, ok := h.logger.override.expiry(); ok {
+		state.Expires = &expires
+	}
+	if components := h.logger.ComponentLevels(); len(components) > 0 {
+		state.Components = make(map[string]string, len(components))
+		for name, level := range components {
+			state.Components[name] = level.String()
+		}
+	}
+	writeLevelJSON(w, http.StatusOK, state)
+}
+
+func (h *levelHandler) setLevel(w http.ResponseWriter, r *http.Request) error {
+	r.Body = http.MaxBytesReader(w, r.Body, maxLevelRequestSize)
+	var req levelRequest
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			return fmt.Errorf("invalid request body, %v", err)
+		}
+	} else {
+		if err := r.ParseForm(); err != nil {
+			return fmt.Errorf("invalid request body, %v", err)
+		}
+		req.Logger = r.FormValue("logger")
+		req.Level = r.FormValue("level")
+		req.Duration = r.FormValue("duration")
+	}
+
+	if req.Logger != "" {
+		return h.setComponentLevel(req)
+	}
+	level, err := ParseLevel(req.Level)
+	if err != nil {
+		return err
+	}
+	if req.Duration == "" {
+		h.logger.SetLevel(level)
+		return nil
+	}
+	d, err := time.ParseDuration(req.Duration)
+	if err != nil {
+		return fmt.Errorf("invalid duration, %v", err)
+	}
+	if d <= 0 {
+		return fmt.Errorf("invalid duration, %q is not positive", req.Duration)
+	}
+	h.logger.SetLevelFor(level, d)
+	return nil
+}
+
+func (h *levelHandler) setComponentLevel(req levelRequest) error {
+	if req.Duration != "" {
+		return fmt.Errorf("the level of the %q component can't be set for a duration", req.Logger)
+	}
+	if req.Level == "" {
+		h.logger.ResetComponentLevel(req.Logger)
+		return nil
+	}
+	level, err := ParseLevel(req.Level)
+	if err != nil {
+		return err
+	}
+	h.logger.SetComponentLevel(req.Logger, level)
+	return nil
+}
+
+func writeLevelJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
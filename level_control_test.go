@@ -0,0 +1,108 @@
+package logrus
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetLevelFor(t *testing.T) {
+	logger := New(InfoLevel)
+
+	logger.SetLevelFor(DebugLevel, 50*time.Millisecond)
+	assert.Equal(t, DebugLevel, logger.Level())
+	logger.SetLevelFor(ErrorLevel, 50*time.Millisecond)
+	assert.Equal(t, ErrorLevel, logger.Level())
+	assert.Eventually(t, func() bool { return logger.Level() == InfoLevel }, time.Second, 5*time.Millisecond)
+}
+
+func TestSetLevelCancelsTheOverride(t *testing.T) {
+	logger := New(InfoLevel)
+
+	logger.SetLevelFor(DebugLevel, 20*time.Millisecond)
+	logger.SetLevel(WarnLevel)
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, WarnLevel, logger.Level())
+}
+
+func serveLevel(t *testing.T, logger *Logger, method, target, contentType, body string) (int, map[string]interface{}) {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	rec := httptest.NewRecorder()
+	logger.LevelHandler().ServeHTTP(rec, req)
+
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	var response map[string]interface{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	return rec.Code, response
+}
+
+func TestLevelHandler(t *testing.T) {
+	logger := New(InfoLevel)
+
+	code, response := serveLevel(t, logger, http.MethodGet, "/level", "", "")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, map[string]interface{}{"level": "info"}, response)
+
+	code, response = serveLevel(t, logger, http.MethodPut, "/level", "application/json", `{"level":"debug"}`)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "debug", response["level"])
+	assert.Equal(t, DebugLevel, logger.Level())
+
+	code, response = serveLevel(t, logger, http.MethodPost, "/level?level=warn", "", "")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "warning", response["level"])
+	assert.Equal(t, WarnLevel, logger.Level())
+
+	code, response = serveLevel(t, logger, http.MethodPost, "/level", "application/x-www-form-urlencoded", "level=error")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, ErrorLevel, logger.Level())
+}
+
+func TestLevelHandlerTemporaryOverride(t *testing.T) {
+	logger := New(InfoLevel)
+
+	code, response := serveLevel(t, logger, http.MethodPut, "/level", "application/json", `{"level":"debug","duration":"50ms"}`)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "debug", response["level"])
+	assert.Contains(t, response, "expires")
+
+	assert.Eventually(t, func() bool { return logger.Level() == InfoLevel }, time.Second, 5*time.Millisecond)
+	_, response = serveLevel(t, logger, http.MethodGet, "/level", "", "")
+	assert.Equal(t, map[string]interface{}{"level": "info"}, response)
+}
+
+func TestLevelHandlerErrors(t *testing.T) {
+	logger := New(InfoLevel)
+
+	testCases := []struct {
+		method      string
+		target      string
+		contentType string
+		body        string
+		code        int
+	}{
+		{http.MethodPut, "/level", "application/json", `{"level":"loud"}`, http.StatusBadRequest},
+		{http.MethodPut, "/level", "application/json", `{`, http.StatusBadRequest},
+		{http.MethodPut, "/level?level=debug&duration=forever", "", "", http.StatusBadRequest},
+		{http.MethodPut, "/level?level=debug&duration=-1s", "", "", http.StatusBadRequest},
+		{http.MethodPut, "/level", "application/json", `{"level":"debug","pad":"` + strings.Repeat("x", maxLevelRequestSize) + `"}`, http.StatusBadRequest},
+		{http.MethodPost, "/level", "application/x-www-form-urlencoded", "level=debug&pad=" + strings.Repeat("x", maxLevelRequestSize), http.StatusBadRequest},
+		{http.MethodDelete, "/level", "", "", http.StatusMethodNotAllowed},
+	}
+
+	for _, tc := range testCases {
+		code, response := serveLevel(t, logger, tc.method, tc.target, tc.contentType, tc.body)
+		assert.Equal(t, tc.code, code, tc.target)
+		assert.Contains(t, response, "error")
+	}
+	assert.Equal(t, InfoLevel, logger.Level())
+}
@@ -0,0 +1,25 @@
+//go:build !windows
+// +build !windows
+
+package logrus
+
+import (
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestToggleLevelOnSignal(t *testing.T) {
+	logger := New(WarnLevel)
+	stop := logger.ToggleLevelOnSignal(DebugLevel)
+	defer stop()
+
+	require.NoError(t, syscall.Kill(syscall.Getpid(), syscall.SIGUSR1))
+	assert.Eventually(t, func() bool { return logger.Level() == DebugLevel }, time.Second, 5*time.Millisecond)
+
+	require.NoError(t, syscall.Kill(syscall.Getpid(), syscall.SIGUSR1))
+	assert.Eventually(t, func() bool { return logger.Level() == WarnLevel }, time.Second, 5*time.Millisecond)
+}
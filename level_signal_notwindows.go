@@ -0,0 +1,12 @@
+//go:build !windows
+// +build !windows
+
+package logrus
+
+import (
+	"os"
+	"syscall"
+)
+
+// defaultToggleSignals are the signals ToggleLevelOnSignal listens to by default
+var defaultToggleSignals = []os.Signal{syscall.SIGUSR1}
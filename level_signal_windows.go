@@ -0,0 +1,6 @@
+package logrus
+
+import "os"
+
+// defaultToggleSignals is empty, since there is no user signal on windows
+var defaultToggleSignals []os.Signal
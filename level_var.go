@@ -0,0 +1,73 @@
+package logrus
+
+import (
+	"sync/atomic"
+)
+
+// LevelVar is a Level which can be shared by several loggers and changed atomically, so a
+// single configuration reload changes the level of all of them:
+//
+//	var level logrus.LevelVar
+//	api.SetLevelVar(&level)
+//	db.SetLevelVar(&level)
+//
+//	level.Set(logrus.DebugLevel) // both loggers write the debug entries
+//
+// The zero LevelVar is at PanicLevel. LevelVar implements encoding.TextMarshaler and
+// encoding.TextUnmarshaler, so it can be loaded from a configuration file directly.
+type LevelVar struct {
+	level uint32
+}
+
+// NewLevelVar creates a LevelVar at the level.
+func NewLevelVar(level Level) *LevelVar {
+	return &LevelVar{level: uint32(level)}
+}
+
+// Level returns the level of the variable.
+func (v *LevelVar) Level() Level {
+	return Level(atomic.LoadUint32(&v.level))
+}
+
+// Set sets the level of the variable.
+func (v *LevelVar) Set(level Level) {
+	atomic.StoreUint32(&v.level, uint32(level))
+}
+
+// String returns the name of the level of the variable.
+func (v *LevelVar) String() string {
+	return v.Level().String()
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (v *LevelVar) MarshalText() ([]byte, error) {
+	return v.Level().MarshalText()
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, parsing the level with ParseLevel.
+func (v *LevelVar) UnmarshalText(text []byte) error {
+	level, err := ParseLevel(string(text))
+	if err != nil {
+		return err
+	}
+	v.Set(level)
+	return nil
+}
+
+// SetLevelVar makes the Logger use the level of the variable, shared with the other loggers
+// using it. SetLevel, SetLevelFor and the other methods changing the Logger's level then change
+// the variable. A nil variable detaches the Logger, which keeps the variable's current level.
+func (logger *Logger) SetLevelVar(v *LevelVar) {
+	logger.override.cancel()
+	if v == nil {
+		level := logger.Level()
+		atomic.StoreUint32((*uint32)(&logger.level), uint32(level))
+	}
+	logger.levelVar.Store(v)
+}
+
+// levelVarOf returns the variable the Logger's level is read from, if any
+func (logger *Logger) levelVarOf() *LevelVar {
+	v, _ := logger.levelVar.Load().(*LevelVar)
+	return v
+}
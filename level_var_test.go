@@ -0,0 +1,90 @@
+package logrus
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLevelText(t *testing.T) {
+	var config struct {
+		Level Level `json:"level"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(`{"level":"WARN"}`), &config))
+	assert.Equal(t, WarnLevel, config.Level)
+
+	config.Level = TraceLevel
+	encoded, err := json.Marshal(config)
+	require.NoError(t, err)
+	assert.Equal(t, `{"level":"trace"}`, string(encoded))
+
+	assert.Error(t, json.Unmarshal([]byte(`{"level":"loud"}`), &config))
+	_, err = Level(42).MarshalText()
+	assert.Error(t, err)
+}
+
+func TestLevelFlag(t *testing.T) {
+	level := InfoLevel
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	flags.SetOutput(&bytes.Buffer{})
+	flags.Var(&level, "log-level", "the logging level")
+
+	require.NoError(t, flags.Parse([]string{"-log-level", "debug"}))
+	assert.Equal(t, DebugLevel, level)
+	assert.Equal(t, "debug", flags.Lookup("log-level").Value.String())
+	assert.Error(t, flags.Parse([]string{"-log-level", "loud"}))
+}
+
+func TestLevelVarIsShared(t *testing.T) {
+	v := NewLevelVar(WarnLevel)
+	var first, second bytes.Buffer
+	api, db := New(InfoLevel), New(InfoLevel)
+	api.SetOutput(&first)
+	db.SetOutput(&second)
+	api.SetLevelVar(v)
+	db.SetLevelVar(v)
+
+	api.Info("api info")
+	db.Info("db info")
+	assert.Empty(t, first.String())
+	assert.Empty(t, second.String())
+
+	require.NoError(t, v.UnmarshalText([]byte("debug")))
+	api.AsDebug().WithField("key", "value").Write("api debug")
+	db.Debug("db debug")
+	assert.Contains(t, first.String(), "api debug")
+	assert.Contains(t, second.String(), "db debug")
+
+	db.SetLevel(ErrorLevel)
+	assert.Equal(t, ErrorLevel, v.Level())
+	assert.Equal(t, ErrorLevel, api.Level())
+}
+
+func TestSetLevelVarNilDetaches(t *testing.T) {
+	v := NewLevelVar(DebugLevel)
+	logger := New(InfoLevel)
+	logger.SetLevelVar(v)
+	logger.SetLevelVar(nil)
+	assert.Equal(t, DebugLevel, logger.Level())
+
+	v.Set(ErrorLevel)
+	assert.Equal(t, DebugLevel, logger.Level())
+}
+
+func TestLevelVarText(t *testing.T) {
+	var config struct {
+		Level *LevelVar `json:"level"`
+	}
+	config.Level = new(LevelVar)
+	require.NoError(t, json.Unmarshal([]byte(`{"level":"error"}`), &config))
+	assert.Equal(t, ErrorLevel, config.Level.Level())
+	assert.Equal(t, "error", config.Level.String())
+
+	encoded, err := json.Marshal(config)
+	require.NoError(t, err)
+	assert.Equal(t, `{"level":"error"}`, string(encoded))
+}
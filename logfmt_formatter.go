@@ -0,0 +1,309 @@
+package logrus
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// LogfmtFormatter formats logs into logfmt (https://brandur.org/logfmt). Unlike TextFormatter,
+// it follows the logfmt rules strictly, so the lines can be parsed back with Parse:
+//
+// * The keys are made of any printable characters but space, `=` and `"`. The invalid
+// characters are replaced with `_`.
+//
+// * The values containing a space, a control character, `=`, `"` or invalid UTF-8 are quoted.
+// The quotes, backslashes and control characters are escaped inside the quotes, and the bytes
+// of invalid UTF-8 are written as `\xNN`, so that they are parsed back unchanged.
+//
+// The time, level and message are written first, followed by the caller, if reported, and the
+// fields sorted by key.
+type LogfmtFormatter struct {
+	// TimestampFormat to use for the time field. The default is time.RFC3339.
+	TimestampFormat string
+
+	// DisableTimestamp allows disabling the time field.
+	DisableTimestamp bool
+
+	// CallerPrettyfier can be set by the user to modify the content of the
+	// function and file keys when the Logger is reporting the caller. If either
+	// of the returned values is the empty string, the corresponding key will be
+	// omitted.
+	CallerPrettyfier func(*runtime.Frame) (function string, file string)
+}
+
+// Format renders a single log entry
+func (f *LogfmtFormatter) Format(entry *Entry) ([]byte, error) {
+	b := make([]byte, 0, 256)
+
+	if !f.DisableTimestamp && entry.hasTime() {
+		timestampFormat := f.TimestampFormat
+		if timestampFormat == "" {
+			timestampFormat = defaultTimestampFormat
+		}
+		b = appendLogfmtPair(b, timeKey, entry.Time.Format(timestampFormat))
+	}
+	b = appendLogfmtPair(b, levelKey, entry.Level.String())
+	b = appendLogfmtPair(b, messageKey, entry.Message)
+
+	if entry.Caller != nil {
+		funcVal, fileVal := callerFields(entry.Caller, f.CallerPrettyfier)
+		if funcVal != "" {
+			b = appendLogfmtPair(b, funcKey, funcVal)
+		}
+		if fileVal != "" {
+			b = appendLogfmtPair(b, fileKey, fileVal)
+		}
+	}
+
+	fields := appendFieldRefs(make(fieldRefs, 0, len(entry.Data)+len(entry.Typed)), entry, true)
+	sort.Sort(fields)
+	for _, field := range fields {
+		if field.typed < 0 {
+			b = appendLogfmtPair(b, field.name, logfmtValue(entry.Data[field.key]))
+		} else {
+			b = appendLogfmtPair(b, field.name, entry.Typed[field.typed].text())
+		}
+	}
+
+	return append(b, '\n'), nil
+}
+
+func logfmtValue(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case error:
+		return v.Error()
+	}
+	return fmt.Sprint(value)
+}
+
+func appendLogfmtPair(b []byte, key string, value string) []byte {
+	if len(b) > 0 {
+		b = append(b, ' ')
+	}
+	b = appendLogfmtKey(b, key)
+	b = append(b, '=')
+	return appendLogfmtValue(b, value)
+}
+
+// appendLogfmtKey appends the key, replacing the invalid characters with `_`
+func appendLogfmtKey(b []byte, key string) []byte {
+	if key == "" {
+		return append(b, '_')
+	}
+	for i, r := range key {
+		if r <= ' ' || r == '=' || r == '"' || r == utf8.RuneError || r == 0x7f {
+			b = append(b, '_')
+			continue
+		}
+		b = append(b, key[i:i+utf8.RuneLen(r)]...)
+	}
+	return b
+}
+
+func appendLogfmtValue(b []byte, value string) []byte {
+	if !logfmtNeedsQuoting(value) {
+		return append(b, value...)
+	}
+
+	b = append(b, '"')
+	start := 0
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		if c >= utf8.RuneSelf {
+			r, size := utf8.DecodeRuneInString(value[i:])
+			if r != utf8.RuneError || size != 1 {
+				i += size - 1
+				continue
+			}
+		} else if c >= 0x20 && c != '"' && c != '\\' && c != 0x7f {
+			continue
+		}
+		b = append(b, value[start:i]...)
+		switch {
+		case c >= utf8.RuneSelf:
+			// Invalid UTF-8, which strconv.Unquote turns back into the byte
+			b = append(b, '\\', 'x', hex[c>>4], hex[c&0xF])
+		case c == '"' || c == '\\':
+			b = append(b, '\\', c)
+		case c == '\n':
+			b = append(b, '\\', 'n')
+		case c == '\r':
+			b = append(b, '\\', 'r')
+		case c == '\t':
+			b = append(b, '\\', 't')
+		default:
+			b = append(b, '\\', 'u', '0', '0', hex[c>>4], hex[c&0xF])
+		}
+		start = i + 1
+	}
+	b = append(b, value[start:]...)
+	return append(b, '"')
+}
+
+func logfmtNeedsQuoting(value string) bool {
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		if c <= ' ' || c == '=' || c == '"' || c == 0x7f {
+			return true
+		}
+	}
+	return !utf8.ValidString(value)
+}
+
+// ParseLogfmt parses a line written by LogfmtFormatter with the default settings, by a Logger
+// which isn't reporting the caller. The time, level and msg keys are set to the corresponding
+// fields of the entry, and the other keys to Data, with their values as strings. A key without a
+// value is set to nil.
+//
+// The time is parsed with the time.RFC3339 layout, which also accepts fractional seconds. Use
+// LogfmtFormatter.Parse for the lines written with another layout or with the caller.
+func ParseLogfmt(line string) (*Entry, error) {
+	return new(LogfmtFormatter).Parse(line, false)
+}
+
+// Parse parses a line written by the formatter, like ParseLogfmt. The time is parsed with the
+// TimestampFormat of the formatter, in the local time zone if the layout has none. The func and
+// file keys are set to the Caller of the entry if reportCaller is true, when the lines are written
+// by a Logger reporting the caller, and are kept in Data otherwise.
+func (f *LogfmtFormatter) Parse(line string, reportCaller bool) (*Entry, error) {
+	timestampFormat := f.TimestampFormat
+	if timestampFormat == "" {
+		timestampFormat = defaultTimestampFormat
+	}
+	entry := &Entry{Data: make(Fields)}
+	var funcVal, fileVal string
+
+	p := logfmtParser{line: strings.TrimRight(line, "\r\n")}
+	for {
+		key, value, hasValue, err := p.next()
+		if err != nil {
+			return nil, err
+		}
+		if key == "" {
+			break
+		}
+		if !hasValue {
+			entry.Data[key] = nil
+			continue
+		}
+
+		switch key {
+		case timeKey:
+			if entry.Time, err = time.ParseInLocation(timestampFormat, value, time.Local); err != nil {
+				return nil, fmt.Errorf("logfmt: invalid time: %v", err)
+			}
+		case levelKey:
+			if entry.Level, err = ParseLevel(value); err != nil {
+				return nil, fmt.Errorf("logfmt: %v", err)
+			}
+		case messageKey:
+			entry.Message = value
+		case funcKey:
+			if !reportCaller {
+				entry.Data[key] = value
+				break
+			}
+			funcVal = value
+		case fileKey:
+			if !reportCaller {
+				entry.Data[key] = value
+				break
+			}
+			fileVal = value
+		default:
+			entry.Data[key] = value
+		}
+	}
+
+	if funcVal != "" || fileVal != "" {
+		entry.Caller = &runtime.Frame{Function: funcVal, File: fileVal}
+		if i := strings.LastIndexByte(fileVal, ':'); i >= 0 {
+			if line, err := strconv.Atoi(fileVal[i+1:]); err == nil {
+				entry.Caller.File, entry.Caller.Line = fileVal[:i], line
+			}
+		}
+	}
+	return entry, nil
+}
+
+var errLogfmtUnterminatedQuote = errors.New("logfmt: unterminated quoted value")
+
+type logfmtParser struct {
+	line string
+	pos  int
+}
+
+// next returns the next key and value, or an empty key at the end of the line
+func (p *logfmtParser) next() (key string, value string, hasValue bool, err error) {
+	for p.pos < len(p.line) && p.line[p.pos] <= ' ' {
+		p.pos++
+	}
+	if p.pos == len(p.line) {
+		return "", "", false, nil
+	}
+
+	start := p.pos
+	for p.pos < len(p.line) && p.line[p.pos] > ' ' && p.line[p.pos] != '=' {
+		if p.line[p.pos] == '"' {
+			return "", "", false, fmt.Errorf("logfmt: unexpected '\"' in key at %d", p.pos)
+		}
+		p.pos++
+	}
+	key = p.line[start:p.pos]
+	if key == "" {
+		return "", "", false, fmt.Errorf("logfmt: unexpected '=' at %d", p.pos)
+	}
+	if p.pos == len(p.line) || p.line[p.pos] != '=' {
+		return key, "", false, nil
+	}
+	p.pos++
+
+	if p.pos < len(p.line) && p.line[p.pos] == '"' {
+		if value, err = p.quotedValue(); err != nil {
+			return "", "", false, err
+		}
+		if p.pos < len(p.line) && p.line[p.pos] > ' ' {
+			return "", "", false, fmt.Errorf("logfmt: missing space after the quoted value at %d", p.pos)
+		}
+		return key, value, true, nil
+	}
+
+	start = p.pos
+	for p.pos < len(p.line) && p.line[p.pos] > ' ' {
+		if c := p.line[p.pos]; c == '"' || c == '=' {
+			return "", "", false, fmt.Errorf("logfmt: unexpected '%c' in value at %d", c, p.pos)
+		}
+		p.pos++
+	}
+	return key, p.line[start:p.pos], true, nil
+}
+
+func (p *logfmtParser) quotedValue() (string, error) {
+	start := p.pos
+	p.pos++
+	escaped := false
+	for ; p.pos < len(p.line); p.pos++ {
+		switch c := p.line[p.pos]; {
+		case escaped:
+			escaped = false
+		case c == '\\':
+			escaped = true
+		case c == '"':
+			p.pos++
+			value, err := strconv.Unquote(p.line[start:p.pos])
+			if err != nil {
+				return "", fmt.Errorf("logfmt: invalid quoted value at %d: %v", start, err)
+			}
+			return value, nil
+		}
+	}
+	return "", errLogfmtUnterminatedQuote
+}
@@ -0,0 +1,175 @@
+package logrus
+
+import (
+	"errors"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogfmtFormatter(t *testing.T) {
+	entry := WithFields(Fields{
+		"plain":     "value",
+		"spaces":    "a value",
+		"equals":    "a=b",
+		"quotes":    `say "hi"`,
+		"newline":   "line 1\nline 2",
+		"empty":     "",
+		"number":    42,
+		"error":     errors.New("wild walrus"),
+		"bad key=":  "x",
+		"unicode":   "wälrus",
+		levelKey:    "user level",
+		"backslash": `C:\path`,
+	}).With(Duration("elapsed", time.Second))
+	entry.Time = time.Date(2018, 3, 8, 10, 30, 0, 0, time.UTC)
+	entry.Level = WarnLevel
+	entry.Message = "hello world"
+
+	b, err := (&LogfmtFormatter{}).Format(entry)
+	require.NoError(t, err)
+	assert.Equal(t, `time=2018-03-08T10:30:00Z level=warning msg="hello world" `+
+		`backslash=C:\path bad_key_=x elapsed=1s empty= equals="a=b" error="wild walrus" `+
+		`fields.level="user level" newline="line 1\nline 2" number=42 plain=value `+
+		`quotes="say \"hi\"" spaces="a value" unicode=wälrus`+"\n", string(b))
+}
+
+func TestLogfmtFormatterCaller(t *testing.T) {
+	entry := &Entry{
+		Level:  InfoLevel,
+		Caller: &runtime.Frame{Function: "pkg.Func", File: "/src/file.go", Line: 7},
+	}
+
+	b, err := (&LogfmtFormatter{DisableTimestamp: true}).Format(entry)
+	require.NoError(t, err)
+	assert.Equal(t, "level=info msg= func=pkg.Func file=/src/file.go:7\n", string(b))
+
+	parsed, err := (&LogfmtFormatter{}).Parse(string(b), true)
+	require.NoError(t, err)
+	assert.Equal(t, entry.Caller, parsed.Caller)
+	assert.Empty(t, parsed.Data)
+}
+
+func TestParseLogfmtKeepsTheCallerKeysAsFields(t *testing.T) {
+	entry := WithFields(Fields{funcKey: "x", fileKey: "y.go:1"})
+	entry.Level = InfoLevel
+
+	b, err := (&LogfmtFormatter{DisableTimestamp: true}).Format(entry)
+	require.NoError(t, err)
+
+	parsed, err := ParseLogfmt(string(b))
+	require.NoError(t, err)
+	assert.Nil(t, parsed.Caller)
+	assert.Equal(t, Fields{funcKey: "x", fileKey: "y.go:1"}, parsed.Data)
+}
+
+func TestParseLogfmtWithTheTimestampFormat(t *testing.T) {
+	formatter := &LogfmtFormatter{TimestampFormat: "2006-01-02 15:04:05"}
+	entry := &Entry{
+		Time:  time.Date(2018, 3, 8, 10, 30, 0, 0, time.Local),
+		Level: InfoLevel,
+	}
+
+	b, err := formatter.Format(entry)
+	require.NoError(t, err)
+
+	parsed, err := formatter.Parse(string(b), false)
+	require.NoError(t, err)
+	assert.True(t, entry.Time.Equal(parsed.Time), parsed.Time)
+
+	_, err = ParseLogfmt(string(b))
+	assert.Error(t, err)
+}
+
+func TestParseLogfmt(t *testing.T) {
+	entry, err := ParseLogfmt(`time=2018-03-08T10:30:00.5Z level=error msg="hello \"world\"" key=value bare empty= quoted="a\tb"` + "\n")
+	require.NoError(t, err)
+
+	assert.Equal(t, time.Date(2018, 3, 8, 10, 30, 0, 5e8, time.UTC), entry.Time)
+	assert.Equal(t, ErrorLevel, entry.Level)
+	assert.Equal(t, `hello "world"`, entry.Message)
+	assert.Equal(t, Fields{"key": "value", "bare": nil, "empty": "", "quoted": "a\tb"}, entry.Data)
+	assert.Nil(t, entry.Caller)
+}
+
+func TestParseLogfmtErrors(t *testing.T) {
+	lines := []string{
+		`msg="unterminated`,
+		`=value`,
+		`key="a"b=c`,
+		`key=a"b`,
+		`key=a=b`,
+		`level=loud`,
+		`time=yesterday`,
+		`msg="bad \q escape"`,
+	}
+	for _, line := range lines {
+		_, err := ParseLogfmt(line)
+		assert.Error(t, err, line)
+	}
+}
+
+func TestLogfmtRoundTripThroughTheLogger(t *testing.T) {
+	var buffer strings.Builder
+	logger := New(InfoLevel)
+	logger.SetOutput(&buffer)
+	logger.SetFormatter(&LogfmtFormatter{TimestampFormat: time.RFC3339Nano})
+
+	logger.WithFields(Fields{"user": "walrus", "note": "has spaces = and \"quotes\""}).AsWarning().Write("hello\tworld")
+
+	entry, err := ParseLogfmt(buffer.String())
+	require.NoError(t, err)
+	assert.Equal(t, WarnLevel, entry.Level)
+	assert.Equal(t, "hello\tworld", entry.Message)
+	assert.Equal(t, Fields{"user": "walrus", "note": "has spaces = and \"quotes\""}, entry.Data)
+	assert.False(t, entry.Time.IsZero())
+}
+
+func FuzzLogfmtRoundTrip(f *testing.F) {
+	f.Add("key", "value", "message")
+	f.Add("with space", "with space", "")
+	f.Add("a=b", `"quoted"`, "line 1\nline 2")
+	f.Add("", "\x00\x7f\\", "tab\there")
+	f.Add("ключ", "значение", "ü")
+	f.Add("\xff", "a\xffb\xc3", "\xe2\x82 \ufffd")
+
+	f.Add(funcKey, "x", "")
+
+	formatters := []struct {
+		formatter *LogfmtFormatter
+		time      time.Time
+	}{
+		{&LogfmtFormatter{TimestampFormat: time.RFC3339Nano}, time.Date(2018, 3, 8, 10, 30, 0, 123456789, time.UTC)},
+		{&LogfmtFormatter{TimestampFormat: "2006-01-02 15:04:05"}, time.Date(2018, 3, 8, 10, 30, 0, 0, time.Local)},
+	}
+	f.Fuzz(func(t *testing.T, key string, value string, message string) {
+		switch key {
+		case timeKey, levelKey, messageKey:
+			t.Skip("clashing keys are prefixed")
+		}
+
+		for _, tc := range formatters {
+			entry := &Entry{
+				Data:    Fields{key: value},
+				Time:    tc.time,
+				Level:   InfoLevel,
+				Message: message,
+			}
+			b, err := tc.formatter.Format(entry)
+			require.NoError(t, err)
+
+			parsed, err := tc.formatter.Parse(string(b), false)
+			require.NoError(t, err, "%q", b)
+			assert.Equal(t, entry.Message, parsed.Message)
+			assert.Equal(t, entry.Level, parsed.Level)
+			assert.True(t, entry.Time.Equal(parsed.Time), "%q", b)
+
+			expectedKey := string(appendLogfmtKey(nil, key))
+			assert.Equal(t, Fields{expectedKey: value}, parsed.Data, "%q", b)
+		}
+	})
+}
@@ -1,7 +1,7 @@
 package logrus
 
 import (
-	"fmt"
+	"context"
 	"io"
 	"os"
 	"sync"
@@ -26,15 +26,54 @@ type Logger struct {
 	// logged.
 	level Level
 
+	// hooks for the logger instance. These allow firing events based on logging
+	// levels and log entries. For example, to send errors to an error tracking
+	// service, log to StatsD or dump the core on fatal errors.
+	hooks LevelHooks
+
+	// contextExtractors pull the fields out of the entries' context at write time
+	contextExtractors []ContextExtractor
+
+	// reportCaller is set to 1 when the calling method has to be added to the entries
+	reportCaller uint32
+
 	// MutexWrap used to sync writing to the log. Locking is enabled by Default
 	mux MutexWrap
 
 	// Reusable empty entry
 	entryPool sync.Pool
+
+	// async holds the *asyncWriter when the Logger writes asynchronously (see SetAsync)
+	async atomic.Value
+
+	// sampler holds the *sampler when the entries are sampled (see SetSampling)
+	sampler atomic.Value
+
+	// redactor holds the *compiledRedactor masking the sensitive data (see SetRedactor)
+	redactor atomic.Value
+
+	// sinks holds the *sinkSet of the additional outputs (see AddSink)
+	sinks atomic.Value
+
+	// sinkLevel is the most verbose level of the sinks
+	sinkLevel uint32
+
+	// override tracks the temporary level set by SetLevelFor
+	override levelOverride
+
+	// levelVar holds the *LevelVar the level is read from, if any (see SetLevelVar)
+	levelVar atomic.Value
+
+	// exitOptions holds the *ExitOptions of the fatal and panic entries (see SetExitOptions)
+	exitOptions atomic.Value
+
+	// components holds the componentLevels of the named loggers (see SetComponentLevel)
+	components    atomic.Value
+	componentsMux sync.Mutex
 }
 
-// New creates a new instance of Logger. Configuration should be set by changing `formatter` (default TextFormatter),
-// `Out` (default os.Stderr) and `hooks` directly on the default Logger instance.
+// New creates a new instance of Logger. Configuration should be set by calling `SetFormatter` (default TextFormatter),
+// `SetOutput` (default os.Stderr) and `AddHook` on the default Logger instance.
 // It's recommended to make this a global instance called `log`.
 func New(level Level) *Logger {
 	return &Logger{
@@ -44,6 +83,7 @@ func New(level Level) *Logger {
 			DisableColors: true,
 		},
 		level: level,
+		hooks: make(LevelHooks),
 	}
 }
 
@@ -55,6 +95,12 @@ func (logger *Logger) AsLevel(level Level) *Entry {
 	return entry.AsLevel(level)
 }
 
+// AsTrace creates a new entry and sets the level to `trace`
+// Make sure you call this method before calling WithField, WithFields and WithError methods
+func (logger *Logger) AsTrace() *Entry {
+	return logger.AsLevel(TraceLevel)
+}
+
 // AsDebug creates a new entry and sets the level to `debug`
 // Make sure you call this method before calling WithField, WithFields and WithError methods
 func (logger *Logger) AsDebug() *Entry {
@@ -106,6 +152,13 @@ func (logger *Logger) WithField(key string, value interface{}) *Entry {
 	return entry.WithField(key, value)
 }
 
+// With adds typed fields to the log entry. See Entry.With
+func (logger *Logger) With(fields ...Field) *Entry {
+	entry := logger.newEntry()
+	defer logger.releaseEntry(entry)
+	return entry.With(fields...)
+}
+
 // WithError adds an error as single field to the log entry
 func (logger *Logger) WithError(err error) *Entry {
 	entry := logger.newEntry()
@@ -113,6 +166,13 @@ func (logger *Logger) WithError(err error) *Entry {
 	return entry.WithError(err)
 }
 
+// WithContext creates a new log entry object and adds a context to the entry.
+func (logger *Logger) WithContext(ctx context.Context) *Entry {
+	entry := logger.newEntry()
+	defer logger.releaseEntry(entry)
+	return entry.WithContext(ctx)
+}
+
 func (logger *Logger) Print(args ...interface{}){
 	logger.log(InfoLevel, unformatted, "", args...)
 }
@@ -125,6 +185,11 @@ func (logger *Logger) Println(args ...interface{}){
 	logger.log(InfoLevel, newLine, "", args...)
 }
 
+// Tracef logs a formatted string at trace level
+func (logger *Logger) Tracef(format string, args ...interface{}) {
+	logger.log(TraceLevel, formatted, format, args...)
+}
+
 // Debugf logs a formatted string at debug level
 func (logger *Logger) Debugf(format string, args ...interface{}) {
 	logger.log(DebugLevel, formatted, format, args...)
@@ -148,13 +213,16 @@ func (logger *Logger) Errorf(format string, args ...interface{}) {
 // Fatalf logs a formatted string at fatal level and terminated the execution of the application with exit code 1
 func (logger *Logger) Fatalf(format string, args ...interface{}) {
 	logger.log(FatalLevel, formatted, format, args...)
-	Exit(1)
 }
 
 // Panicf logs a formatted string at panic level and terminates the execution of the application with a panic
 func (logger *Logger) Panicf(format string, args ...interface{}) {
 	logger.log(PanicLevel, formatted, format, args...)
-	panic(fmt.Sprint(args...))
+}
+
+// Trace logs a message at trace level
+func (logger *Logger) Trace(args ...interface{}) {
+	logger.log(TraceLevel, unformatted, "", args...)
 }
 
 // Debug logs a message at debug level
@@ -180,14 +248,16 @@ func (logger *Logger) Error(args ...interface{}) {
 // Fatal logs a message at fatal level and terminates the app with exit code 1
 func (logger *Logger) Fatal(args ...interface{}) {
 	logger.log(FatalLevel, unformatted, "", args...)
-	Exit(1)
 }
 
 // Panic logs a message at panic level and terminates the execution of the application with a panic
 func (logger *Logger) Panic(args ...interface{}) {
-	msg := fmt.Sprint(args...)
 	logger.log(PanicLevel, unformatted, "", args...)
-	panic(msg)
+}
+
+// Traceln logs a message followed by a new line at trace level
+func (logger *Logger) Traceln(args ...interface{}) {
+	logger.log(TraceLevel, newLine, "", args...)
 }
 
 // Debugln logs a message followed by a new line at debug level
@@ -213,14 +283,11 @@ func (logger *Logger) Errorln(args ...interface{}) {
 //Fatalln logs a message followed by a new line at fatal level and terminates the execution of the application with exit code 1
 func (logger *Logger) Fatalln(args ...interface{}) {
 	logger.log(FatalLevel, newLine, "", args...)
-	Exit(1)
 }
 
 // Panicln logs a message followed by a new line at panic level and terminates the execution of the application with a panic
 func (logger *Logger) Panicln(args ...interface{}) {
-	msg := fmt.Sprint(args...)
-	logger.log(PanicLevel, newLine, "", msg)
-	panic(msg)
+	logger.log(PanicLevel, newLine, "", args...)
 }
 
 //SetNoLock when file is opened with appending mode, it's safe to
@@ -268,14 +335,95 @@ func (logger *Logger) UseTextFormatter() {
 	}
 }
 
+// AddHook adds a hook to the Logger's hooks.
+func (logger *Logger) AddHook(hook Hook) {
+	logger.mux.Lock()
+	defer logger.mux.Unlock()
+	if logger.hooks == nil {
+		logger.hooks = make(LevelHooks)
+	}
+	logger.hooks.Add(hook)
+}
+
+// ReplaceHooks replaces the Logger's hooks and returns the old ones.
+func (logger *Logger) ReplaceHooks(hooks LevelHooks) LevelHooks {
+	logger.mux.Lock()
+	defer logger.mux.Unlock()
+	oldHooks := logger.hooks
+	logger.hooks = hooks
+	return oldHooks
+}
 
-// SetLevel sets the log level of the Logger object
+// SetLevel sets the log level of the Logger object. It cancels the restoration of the previous
+// level scheduled by SetLevelFor.
 func (logger *Logger) SetLevel(level Level) {
+	logger.override.cancel()
+	logger.storeLevel(level)
+}
+
+func (logger *Logger) storeLevel(level Level) {
+	if v := logger.levelVarOf(); v != nil {
+		v.Set(level)
+		return
+	}
 	atomic.StoreUint32((*uint32)(&logger.level), uint32(level))
 }
 
+// AddContextExtractor registers a function which extracts fields from the context of the
+// entries (see WithContext). The extractors run in the order they were added when an entry
+// with a context gets written, and the fields they return are added to the entry.
+func (logger *Logger) AddContextExtractor(extractor ContextExtractor) {
+	logger.mux.Lock()
+	defer logger.mux.Unlock()
+	logger.contextExtractors = append(logger.contextExtractors, extractor)
+}
+
+// extractContextFields runs the context extractors on ctx. It returns nil if there is
+// no context or no extractor.
+func (logger *Logger) extractContextFields(ctx context.Context) Fields {
+	if ctx == nil {
+		return nil
+	}
+	logger.mux.Lock()
+	extractors := logger.contextExtractors
+	logger.mux.Unlock()
+
+	var fields Fields
+	for _, extractor := range extractors {
+		extracted := extractor(ctx)
+		if len(extracted) == 0 {
+			continue
+		}
+		if fields == nil {
+			fields = make(Fields, len(extracted))
+		}
+		for k, v := range extracted {
+			fields[k] = v
+		}
+	}
+	return fields
+}
+
+// SetReportCaller enables or disables reporting the calling method (file, line and
+// function) as the `file` and `func` fields of the log entries.
+func (logger *Logger) SetReportCaller(reportCaller bool) {
+	var value uint32
+	if reportCaller {
+		value = 1
+	}
+	atomic.StoreUint32(&logger.reportCaller, value)
+}
+
+// ReportCaller returns true if the Logger is reporting the calling method.
+func (logger *Logger) ReportCaller() bool {
+	return atomic.LoadUint32(&logger.reportCaller) == 1
+}
+
 // Level gets the Logger's current level value
 func (logger *Logger) Level() Level {
+	if v := logger.levelVarOf(); v != nil {
+		return v.Level()
+	}
 	return Level(atomic.LoadUint32((*uint32)(&logger.level)))
 }
 
@@ -284,18 +432,28 @@ func (logger *Logger) releaseEntry(entry *Entry) {
 }
 
 func (logger *Logger) log(level Level, mode formatMode, format string, args ...interface{}) {
-	if logger.Level() >= level {
+	if logger.IsLevelEnabled(level) && logger.sample(level, nil, nil, mode, format, args) {
 		entry := logger.newEntry()
 		message := constructMessage(mode, format, args...)
 		entry.Level = level
 		entry.log(message)
-		logger.releaseEntry(entry)
+		if !terminates(level) {
+			logger.releaseEntry(entry)
+		}
+	} else if terminates(level) {
+		// The fatal and panic entries terminate the program even if they are not written
+		entry := NewEntry(logger)
+		entry.Level = level
+		entry.Message = constructMessage(mode, format, args...)
+		logger.terminate(entry)
 	}
 }
 
 func (logger *Logger) newEntry() *Entry {
 	entry, ok := logger.entryPool.Get().(*Entry)
 	if ok {
+		// The pooled entries may have been used to log at a different level
+		entry.Level = logger.Level()
 		return entry
 	}
 	return NewEntry(logger)
@@ -1,8 +1,10 @@
 package logrus
 
 import (
+	"io/ioutil"
 	"os"
 	"testing"
+	"time"
 )
 
 // smallFields is a small size Data set for benchmarking
@@ -59,3 +61,66 @@ func doLoggerBenchmarkNoLock(b *testing.B, out *os.File, formatter Formatter, fi
 		}
 	})
 }
+
+func BenchmarkLoggerMapFieldsJSON(b *testing.B) {
+	logger := New(InfoLevel)
+	logger.SetOutput(ioutil.Discard)
+	logger.SetFormatter(new(JSONFormatter))
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			logger.WithFields(Fields{
+				"string":   "value",
+				"int":      42,
+				"float":    0.5,
+				"duration": time.Second,
+			}).Write("aaa")
+		}
+	})
+}
+
+func BenchmarkLoggerTypedFieldsJSON(b *testing.B) {
+	logger := New(InfoLevel)
+	logger.SetOutput(ioutil.Discard)
+	logger.SetFormatter(new(JSONFormatter))
+	entry := logger.AsInfo()
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			entry.WriteWith("aaa",
+				String("string", "value"),
+				Int("int", 42),
+				Float64("float", 0.5),
+				Duration("duration", time.Second),
+			)
+		}
+	})
+}
+
+func BenchmarkLoggerTypedFieldsText(b *testing.B) {
+	logger := New(InfoLevel)
+	logger.SetOutput(ioutil.Discard)
+	entry := logger.AsInfo()
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			entry.WriteWith("aaa",
+				String("string", "value"),
+				Int("int", 4242),
+				Float64("float", 0.5),
+				Bool("bool", true),
+			)
+		}
+	})
+}
+
+func BenchmarkLoggerTypedFieldsDisabledLevel(b *testing.B) {
+	logger := New(InfoLevel)
+	logger.SetOutput(ioutil.Discard)
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			logger.AsDebug().With(String("string", "value"), Int("int", 42)).Write("aaa")
+		}
+	})
+}
@@ -1,6 +1,7 @@
 package logrus
 
 import (
+	"context"
 	"fmt"
 	"strings"
 )
@@ -8,12 +9,18 @@ import (
 // Fields type, used to pass to `WithFields`.
 type Fields map[string]interface{}
 
+// ContextExtractor extracts the fields from a context, e.g. the request ID or the trace ID
+// stored in the context by a middleware. See Logger.AddContextExtractor.
+type ContextExtractor func(ctx context.Context) Fields
+
 // level type
 type Level uint32
 
 // Convert the level to a string. E.g. PanicLevel becomes "panic".
 func (level Level) String() string {
 	switch level {
+	case TraceLevel:
+		return "trace"
 	case DebugLevel:
 		return "debug"
 	case InfoLevel:
@@ -28,6 +35,9 @@ func (level Level) String() string {
 		return "panic"
 	}
 
+	if name, ok := customLevelName(level); ok {
+		return name
+	}
 	return "unknown"
 }
 
@@ -46,13 +56,46 @@ func ParseLevel(lvl string) (Level, error) {
 		return InfoLevel, nil
 	case "debug":
 		return DebugLevel, nil
+	case "trace":
+		return TraceLevel, nil
 	}
 
+	if level, ok := customLevelByName(strings.ToLower(lvl)); ok {
+		return level, nil
+	}
 	var l Level
 	return l, fmt.Errorf("not a valid logrus level: %q", lvl)
 }
 
-// A constant exposing all logging levels
+// MarshalText implements encoding.TextMarshaler, so the levels are written by name in the
+// JSON, YAML or TOML documents.
+func (level Level) MarshalText() ([]byte, error) {
+	name := level.String()
+	if name == "unknown" {
+		return nil, fmt.Errorf("not a valid logrus level: %d", uint32(level))
+	}
+	return []byte(name), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, parsing the level with ParseLevel.
+func (level *Level) UnmarshalText(text []byte) error {
+	l, err := ParseLevel(string(text))
+	if err != nil {
+		return err
+	}
+	*level = l
+	return nil
+}
+
+// Set implements flag.Value, so a Level can be set from the command line:
+//
+//	level := logrus.InfoLevel
+//	flag.Var(&level, "log-level", "the logging level")
+func (level *Level) Set(s string) error {
+	return level.UnmarshalText([]byte(s))
+}
+
+// A constant exposing all the predefined logging levels. See Levels for the registered levels.
 var AllLevels = []Level{
 	PanicLevel,
 	FatalLevel,
@@ -60,6 +103,7 @@ var AllLevels = []Level{
 	WarnLevel,
 	InfoLevel,
 	DebugLevel,
+	TraceLevel,
 }
 
 // These are the different logging levels. You can set the logging level to log
@@ -69,7 +113,7 @@ const (
 	// Message passed to Debug, Info, ...
 	PanicLevel Level = iota
 	// FatalLevel level. Logs and then calls `os.Exit(1)`. It will exit even if the
-	// logging level is set to Panic.
+	// logging level is set to Panic. See Logger.SetExitOptions to change it.
 	FatalLevel
 	// ErrorLevel level. Logs. Used for errors that should definitely be noted.
 	// Commonly used for hooks to send errors to an error tracking service.
@@ -81,6 +125,9 @@ const (
 	InfoLevel
 	// DebugLevel level. Usually only enabled when debugging. Very verbose logging.
 	DebugLevel
+	// TraceLevel level. Finer-grained entries than Debug, like the dumps of the
+	// messages sent over the wire.
+	TraceLevel
 )
 
 // The FieldLogger interface generalizes the Entry and Logger types
@@ -88,7 +135,10 @@ type FieldLogger interface {
 	WithField(key string, value interface{}) *Entry
 	WithFields(fields Fields) *Entry
 	WithError(err error) *Entry
+	WithContext(ctx context.Context) *Entry
+	With(fields ...Field) *Entry
 
+	Tracef(format string, args ...interface{})
 	Debugf(format string, args ...interface{})
 	Infof(format string, args ...interface{})
 	Warningf(format string, args ...interface{})
@@ -96,6 +146,7 @@ type FieldLogger interface {
 	Fatalf(format string, args ...interface{})
 	Panicf(format string, args ...interface{})
 
+	Trace(args ...interface{})
 	Debug(args ...interface{})
 	Info(args ...interface{})
 	Warning(args ...interface{})
@@ -103,6 +154,7 @@ type FieldLogger interface {
 	Fatal(args ...interface{})
 	Panic(args ...interface{})
 
+	Traceln(args ...interface{})
 	Debugln(args ...interface{})
 	Infoln(args ...interface{})
 	Warningln(args ...interface{})
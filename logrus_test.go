@@ -232,7 +232,27 @@ func TestDoubleLoggingDoesntPrefixPreviousFields(t *testing.T) {
 
 }
 
+func TestTrace(t *testing.T) {
+	LogAndAssertJSON(t, func(log *Logger) {
+		log.SetLevel(TraceLevel)
+		log.Tracef("dump %d", 42)
+	}, func(fields Fields) {
+		assert.Equal(t, fields[messageKey], "dump 42")
+		assert.Equal(t, fields[levelKey], "trace")
+	})
+}
+
+func TestTraceIsDisabledAtDebugLevel(t *testing.T) {
+	var buffer bytes.Buffer
+	logger := New(DebugLevel)
+	logger.Out = &buffer
+	logger.Trace("dump")
+	logger.AsTrace().WithField("key", "value").Write("dump")
+	assert.Empty(t, buffer.String())
+}
+
 func TestConvertLevelToString(t *testing.T) {
+	assert.Equal(t, "trace", TraceLevel.String())
 	assert.Equal(t, "debug", DebugLevel.String())
 	assert.Equal(t, "info", InfoLevel.String())
 	assert.Equal(t, "warning", WarnLevel.String())
@@ -298,6 +318,10 @@ func TestParseLevel(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Equal(t, DebugLevel, l)
 
+	l, err = ParseLevel("TRACE")
+	assert.Nil(t, err)
+	assert.Equal(t, TraceLevel, l)
+
 	l, err = ParseLevel("invalid")
 	assert.Equal(t, "not a valid logrus level: \"invalid\"", err.Error())
 }
@@ -355,3 +379,15 @@ func TestEntryWriter(t *testing.T) {
 	assert.Equal(t, fields["foo"], "bar")
 	assert.Equal(t, fields[levelKey], "warning")
 }
+
+func TestEntryWriterAtTraceLevel(t *testing.T) {
+	cw := channelWriter(make(chan []byte, 1))
+	log := New(TraceLevel)
+	log.Out = cw
+	log.formatter = new(JSONFormatter)
+	log.WriterLevel(TraceLevel).Write([]byte("hello\n"))
+
+	var fields Fields
+	assert.Nil(t, json.Unmarshal(<-cw, &fields))
+	assert.Equal(t, fields[levelKey], "trace")
+}
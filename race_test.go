@@ -0,0 +1,8 @@
+//go:build race
+// +build race
+
+package logrus
+
+// raceEnabled is set when the tests run with the race detector, which makes sync.Pool drop
+// items at random and so skews the allocation counts
+const raceEnabled = true
@@ -0,0 +1,109 @@
+package logrus
+
+import (
+	"runtime/debug"
+)
+
+// defaultPanicMessage is the message of the entries logging a recovered panic
+const defaultPanicMessage = "recovered from panic"
+
+// PanicAction tells what happens once a recovered panic is logged.
+type PanicAction uint8
+
+const (
+	// SwallowPanic stops the panic: the function deferring the recovery returns normally.
+	SwallowPanic PanicAction = iota
+	// Repanic panics again with the recovered value.
+	Repanic
+	// ExitOnPanic terminates the program like the fatal entries do (see Logger.SetExitOptions).
+	ExitOnPanic
+)
+
+// RecoverOptions configures the recovery of the panics by Logger.Recover.
+type RecoverOptions struct {
+	// Level is the level the panic is logged at. The default, when nil, is ErrorLevel. The
+	// entries at the fatal and panic levels neither exit nor panic, the Action decides what
+	// happens next.
+	Level *Level
+
+	// Action tells what happens once the panic is logged. The default is SwallowPanic.
+	Action PanicAction
+
+	// Message is the message of the entry. The default is "recovered from panic".
+	Message string
+
+	// Fields are added to the entry.
+	Fields Fields
+}
+
+// Recover recovers from a panic and logs it, with the recovered value as the `panic` field and
+// the stack trace of the goroutine as the `stack` field. It must be deferred directly:
+//
+//	defer logger.Recover(logrus.RecoverOptions{Action: logrus.Repanic})
+func (logger *Logger) Recover(opts RecoverOptions) {
+	if value := recover(); value != nil {
+		logger.logRecovered(value, opts)
+	}
+}
+
+func (logger *Logger) logRecovered(value interface{}, opts RecoverOptions) {
+	level := ErrorLevel
+	if opts.Level != nil {
+		level = *opts.Level
+	}
+	entry := logger.AsLevel(level)
+	if len(opts.Fields) > 0 {
+		entry = entry.WithFields(opts.Fields)
+	}
+	entry.logPanic(value, opts.Message, opts.Action)
+}
+
+// RecoverAndLog recovers from a panic and logs it with the entry, like Logger.Recover. Like for
+// the other entries, the process exits at the fatal level, and the panic goes on at the panic
+// level, with the recovered value. At the other levels, the panic is stopped. It must be
+// deferred directly:
+//
+//	defer logger.AsError().WithField("worker", id).RecoverAndLog()
+func (entry *Entry) RecoverAndLog() {
+	if value := recover(); value != nil {
+		action := SwallowPanic
+		if entry.Level <= PanicLevel {
+			action = Repanic
+		} else if entry.Level == FatalLevel {
+			action = ExitOnPanic
+		}
+		entry.logPanic(value, "", action)
+	}
+}
+
+// Go runs fn on a new goroutine, recovering and logging its panics with the entry like
+// RecoverAndLog.
+func (entry *Entry) Go(fn func()) {
+	go func() {
+		defer entry.RecoverAndLog()
+		fn()
+	}()
+}
+
+// Go runs fn on a new goroutine, recovering and logging its panics at the error level. See
+// Entry.Go to log them with other fields or at another level.
+func (logger *Logger) Go(fn func()) {
+	logger.AsError().Go(fn)
+}
+
+func (entry *Entry) logPanic(value interface{}, message string, action PanicAction) {
+	if message == "" {
+		message = defaultPanicMessage
+	}
+	if entry.isEnabled() {
+		entry.With(Any(panicKey, value), String(stackKey, string(debug.Stack()))).emit(message)
+	}
+
+	switch action {
+	case Repanic:
+		entry.Logger.flushWithin(exitFlushTimeout)
+		panic(value)
+	case ExitOnPanic:
+		entry.Logger.exit()
+	}
+}
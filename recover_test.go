@@ -0,0 +1,156 @@
+package logrus
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// syncBuffer is a bytes.Buffer safe for the goroutines started by Go
+type syncBuffer struct {
+	mux sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) Bytes() []byte {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	return append([]byte(nil), b.buf.Bytes()...)
+}
+
+func newRecoverLogger(out *syncBuffer) *Logger {
+	logger := New(InfoLevel)
+	logger.SetOutput(out)
+	logger.SetFormatter(new(JSONFormatter))
+	return logger
+}
+
+func decodeEntry(t *testing.T, b []byte) Fields {
+	var fields Fields
+	require.NoError(t, json.Unmarshal(b, &fields))
+	return fields
+}
+
+func TestRecoverAndLogSwallowsThePanic(t *testing.T) {
+	var out syncBuffer
+	logger := newRecoverLogger(&out)
+
+	func() {
+		defer logger.AsError().WithField("worker", 7).RecoverAndLog()
+		panic("boom")
+	}()
+
+	fields := decodeEntry(t, out.Bytes())
+	assert.Equal(t, "recovered from panic", fields["msg"])
+	assert.Equal(t, "error", fields["level"])
+	assert.Equal(t, "boom", fields["panic"])
+	assert.Equal(t, float64(7), fields["worker"])
+	assert.Contains(t, fields["stack"], "TestRecoverAndLogSwallowsThePanic")
+}
+
+func TestRecoverAndLogRepanicsAtPanicLevel(t *testing.T) {
+	var out syncBuffer
+	logger := newRecoverLogger(&out)
+
+	err := errors.New("boom")
+	assert.PanicsWithValue(t, err, func() {
+		defer logger.AsPanic().RecoverAndLog()
+		panic(err)
+	})
+	assert.Equal(t, "panic", decodeEntry(t, out.Bytes())["level"])
+}
+
+func TestRecoverAndLogWithoutPanic(t *testing.T) {
+	var out syncBuffer
+	logger := newRecoverLogger(&out)
+
+	func() {
+		defer logger.AsError().RecoverAndLog()
+	}()
+	assert.Empty(t, out.Bytes())
+}
+
+func TestRecoverOptions(t *testing.T) {
+	var out syncBuffer
+	logger := newRecoverLogger(&out)
+
+	level := WarnLevel
+	assert.PanicsWithValue(t, 42, func() {
+		defer logger.Recover(RecoverOptions{
+			Level:   &level,
+			Action:  Repanic,
+			Message: "worker crashed",
+			Fields:  Fields{"worker": "indexer"},
+		})
+		panic(42)
+	})
+
+	fields := decodeEntry(t, out.Bytes())
+	assert.Equal(t, "worker crashed", fields["msg"])
+	assert.Equal(t, "warning", fields["level"])
+	assert.Equal(t, float64(42), fields["panic"])
+	assert.Equal(t, "indexer", fields["worker"])
+}
+
+func TestRecoverAtErrorLevelByDefault(t *testing.T) {
+	var out syncBuffer
+	logger := newRecoverLogger(&out)
+
+	assert.NotPanics(t, func() {
+		defer logger.Recover(RecoverOptions{})
+		panic("boom")
+	})
+	assert.Equal(t, "error", decodeEntry(t, out.Bytes())["level"])
+}
+
+func TestRecoverAtPanicLevelDoesNotPanic(t *testing.T) {
+	var out syncBuffer
+	logger := newRecoverLogger(&out)
+
+	level := PanicLevel
+	assert.NotPanics(t, func() {
+		defer logger.Recover(RecoverOptions{Level: &level})
+		panic("boom")
+	})
+	assert.Equal(t, "panic", decodeEntry(t, out.Bytes())["level"])
+}
+
+func TestRecoverAtDisabledLevel(t *testing.T) {
+	var out syncBuffer
+	logger := newRecoverLogger(&out)
+
+	level := DebugLevel
+	assert.NotPanics(t, func() {
+		defer logger.Recover(RecoverOptions{Level: &level})
+		panic("boom")
+	})
+	assert.Empty(t, out.Bytes())
+}
+
+func TestGo(t *testing.T) {
+	var out syncBuffer
+	logger := newRecoverLogger(&out)
+	logger.Go(func() {
+		panic("boom")
+	})
+	assert.Eventually(t, func() bool { return len(out.Bytes()) > 0 }, 5*time.Second, time.Millisecond)
+
+	lines := strings.Split(strings.TrimSpace(string(out.Bytes())), "\n")
+	require.Len(t, lines, 1)
+	fields := decodeEntry(t, []byte(lines[0]))
+	assert.Equal(t, "error", fields["level"])
+	assert.Equal(t, "boom", fields["panic"])
+}
@@ -0,0 +1,430 @@
+package logrus
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"path"
+	"reflect"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxRedactionDepth bounds the recursion into the nested values, which may be cyclic
+const maxRedactionDepth = 10
+
+// defaultMask is the replacement used by MaskFull
+const defaultMask = "***"
+
+// Masker returns the replacement of a sensitive value.
+type Masker func(value string) string
+
+// MaskFull replaces the whole value with `***`.
+func MaskFull(string) string {
+	return defaultMask
+}
+
+// MaskWith returns a Masker replacing the whole value with the replacement.
+func MaskWith(replacement string) Masker {
+	return func(string) string {
+		return replacement
+	}
+}
+
+// MaskHash replaces the value with the beginning of its SHA-256 hash, so the occurrences of a
+// value can be correlated without revealing it.
+func MaskHash(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return fmt.Sprintf("sha256:%x", sum[:8])
+}
+
+// MaskPartial returns a Masker which only keeps the last `visible` characters of the value,
+// e.g. `************1111`. The values which are not longer than twice the visible characters
+// are masked entirely.
+func MaskPartial(visible int) Masker {
+	return func(value string) string {
+		n := utf8.RuneCountInString(value)
+		if n <= 2*visible {
+			return strings.Repeat("*", n)
+		}
+		runes := []rune(value)
+		return strings.Repeat("*", n-visible) + string(runes[n-visible:])
+	}
+}
+
+// KeyRule masks the values of the fields whose key matches, at any depth. Exactly one of Key,
+// Glob and Regexp is expected to be set.
+type KeyRule struct {
+	// Key matches the keys exactly, ignoring the case.
+	Key string
+
+	// Glob matches the keys with the path.Match syntax, ignoring the case, e.g. `*_token`.
+	Glob string
+
+	// Regexp matches the keys with a regular expression.
+	Regexp *regexp.Regexp
+
+	// Mask replaces the value, formatted as a string. The default is the Redactor's Mask.
+	Mask Masker
+}
+
+// ValueRule masks the parts of the string values and of the messages matching Pattern. If the
+// pattern has a capturing group, only the first group is masked.
+type ValueRule struct {
+	Pattern *regexp.Regexp
+
+	// Check, if set, confirms that a match is sensitive, e.g. with a checksum.
+	Check func(match string) bool
+
+	// Mask replaces the matches. The default is the Redactor's Mask.
+	Mask Masker
+}
+
+// Redactor masks the sensitive data of the entries before they are passed to the hooks and the
+// formatter, so it applies to all the formatters. The values of the fields are never modified in
+// place: a field which contains sensitive data is replaced in a copy of the entry's fields. The
+// nested maps, slices and structs are redacted recursively; a struct containing sensitive data is
+// replaced with a map of its exported fields.
+type Redactor struct {
+	// Keys are the rules matching the keys of the fields.
+	Keys []KeyRule
+
+	// Values are the rules matching the values of the fields and the messages.
+	Values []ValueRule
+
+	// Mask is the default Masker of the rules. The default is MaskFull.
+	Mask Masker
+}
+
+var (
+	creditCardPattern  = regexp.MustCompile(`\b(?:\d[ -]?){12,18}\d\b`)
+	jwtPattern         = regexp.MustCompile(`\beyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`)
+	bearerTokenPattern = regexp.MustCompile(`(?i)\bbearer\s+([A-Za-z0-9\-._~+/]+=*)`)
+	emailPattern       = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+)
+
+// CreditCards returns a rule masking the credit card numbers which pass the Luhn check.
+func CreditCards(mask Masker) ValueRule {
+	return ValueRule{Pattern: creditCardPattern, Check: luhn, Mask: mask}
+}
+
+// JWTs returns a rule masking the JSON Web Tokens.
+func JWTs(mask Masker) ValueRule {
+	return ValueRule{Pattern: jwtPattern, Mask: mask}
+}
+
+// BearerTokens returns a rule masking the tokens of the `Bearer <token>` authorization headers.
+func BearerTokens(mask Masker) ValueRule {
+	return ValueRule{Pattern: bearerTokenPattern, Mask: mask}
+}
+
+// Emails returns a rule masking the email addresses.
+func Emails(mask Masker) ValueRule {
+	return ValueRule{Pattern: emailPattern, Mask: mask}
+}
+
+// luhn returns true if the digits of the number pass the Luhn checksum
+func luhn(number string) bool {
+	var sum, digits int
+	double := false
+	for i := len(number) - 1; i >= 0; i-- {
+		c := number[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if double {
+			if d *= 2; d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		digits++
+		double = !double
+	}
+	return digits > 0 && sum%10 == 0
+}
+
+// SetRedactor sets the Redactor masking the sensitive data of the entries. Passing nil disables
+// the redaction. The Redactor must not be modified afterwards.
+func (logger *Logger) SetRedactor(redactor *Redactor) {
+	if redactor == nil {
+		logger.redactor.Store((*compiledRedactor)(nil))
+		return
+	}
+	logger.redactor.Store(compileRedactor(redactor))
+}
+
+// compiledRedactor is the Redactor with the defaults resolved and the keys lowercased
+type compiledRedactor struct {
+	keys   []KeyRule
+	values []ValueRule
+}
+
+func compileRedactor(r *Redactor) *compiledRedactor {
+	mask := r.Mask
+	if mask == nil {
+		mask = MaskFull
+	}
+	c := &compiledRedactor{
+		keys:   make([]KeyRule, len(r.Keys)),
+		values: make([]ValueRule, len(r.Values)),
+	}
+	for i, rule := range r.Keys {
+		rule.Key = strings.ToLower(rule.Key)
+		rule.Glob = strings.ToLower(rule.Glob)
+		if rule.Mask == nil {
+			rule.Mask = mask
+		}
+		c.keys[i] = rule
+	}
+	for i, rule := range r.Values {
+		if rule.Mask == nil {
+			rule.Mask = mask
+		}
+		c.values[i] = rule
+	}
+	return c
+}
+
+// redact returns the entry which is about to be written, or a copy of it if it contains sensitive data
+func (entry *Entry) redact() *Entry {
+	r, _ := entry.Logger.redactor.Load().(*compiledRedactor)
+	if r == nil {
+		return entry
+	}
+
+	message, messageChanged := r.redactString(entry.Message)
+	data, dataChanged := r.redactFields(entry.Data, 0)
+	typed, typedChanged := r.redactTyped(entry.Typed)
+	if !messageChanged && !dataChanged && !typedChanged {
+		return entry
+	}
+
+	clone := *entry
+	clone.Message = message
+	clone.Data = data
+	clone.Typed = typed
+	return &clone
+}
+
+// keyMask returns the Masker of the first rule matching the key, or nil
+func (r *compiledRedactor) keyMask(key string) Masker {
+	lower := strings.ToLower(key)
+	for _, rule := range r.keys {
+		switch {
+		case rule.Key != "":
+			if rule.Key == lower {
+				return rule.Mask
+			}
+		case rule.Glob != "":
+			if ok, _ := path.Match(rule.Glob, lower); ok {
+				return rule.Mask
+			}
+		case rule.Regexp != nil:
+			if rule.Regexp.MatchString(key) {
+				return rule.Mask
+			}
+		}
+	}
+	return nil
+}
+
+func (r *compiledRedactor) redactString(s string) (string, bool) {
+	changed := false
+	for _, rule := range r.values {
+		matches := rule.Pattern.FindAllStringSubmatchIndex(s, -1)
+		if len(matches) == 0 {
+			continue
+		}
+		var b strings.Builder
+		last, replaced := 0, false
+		for _, m := range matches {
+			start, end := m[0], m[1]
+			if len(m) >= 4 && m[2] >= 0 {
+				start, end = m[2], m[3]
+			}
+			if rule.Check != nil && !rule.Check(s[start:end]) {
+				continue
+			}
+			b.WriteString(s[last:start])
+			b.WriteString(rule.Mask(s[start:end]))
+			last, replaced = end, true
+		}
+		if !replaced {
+			continue
+		}
+		b.WriteString(s[last:])
+		s = b.String()
+		changed = true
+	}
+	return s, changed
+}
+
+// redactFields returns the fields, or a copy of them if any value has to be masked
+func (r *compiledRedactor) redactFields(fields map[string]interface{}, depth int) (map[string]interface{}, bool) {
+	var redacted map[string]interface{}
+	for k, v := range fields {
+		value, changed := r.redactField(k, v, depth)
+		if !changed {
+			continue
+		}
+		if redacted == nil {
+			redacted = make(map[string]interface{}, len(fields))
+			for k, v := range fields {
+				redacted[k] = v
+			}
+		}
+		redacted[k] = value
+	}
+	if redacted == nil {
+		return fields, false
+	}
+	return redacted, true
+}
+
+func (r *compiledRedactor) redactField(key string, value interface{}, depth int) (interface{}, bool) {
+	if mask := r.keyMask(key); mask != nil {
+		return mask(fmt.Sprint(value)), true
+	}
+	return r.redactValue(value, depth)
+}
+
+func (r *compiledRedactor) redactValue(value interface{}, depth int) (interface{}, bool) {
+	switch v := value.(type) {
+	case nil:
+		return value, false
+	case string:
+		return r.redactString(v)
+	case error:
+		if s, changed := r.redactString(v.Error()); changed {
+			return s, true
+		}
+		return value, false
+	case Fields:
+		if depth >= maxRedactionDepth {
+			return value, false
+		}
+		if m, changed := r.redactFields(v, depth+1); changed {
+			return Fields(m), true
+		}
+		return value, false
+	case map[string]interface{}:
+		if depth >= maxRedactionDepth {
+			return value, false
+		}
+		return r.redactFields(v, depth+1)
+	}
+
+	if depth >= maxRedactionDepth {
+		return value, false
+	}
+	return r.redactReflected(reflect.ValueOf(value), depth+1)
+}
+
+// redactReflected walks the maps with string keys, the slices, the arrays and the structs
+func (r *compiledRedactor) redactReflected(v reflect.Value, depth int) (interface{}, bool) {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return nil, false
+		}
+		return r.redactReflected(v.Elem(), depth)
+
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return nil, false
+		}
+		m := make(map[string]interface{}, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			m[iter.Key().String()] = valueInterface(iter.Value())
+		}
+		return r.redactFields(m, depth)
+
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return nil, false
+		}
+		var redacted []interface{}
+		for i := 0; i < v.Len(); i++ {
+			item, changed := r.redactValue(valueInterface(v.Index(i)), depth)
+			if changed && redacted == nil {
+				redacted = make([]interface{}, v.Len())
+				for j := 0; j < i; j++ {
+					redacted[j] = valueInterface(v.Index(j))
+				}
+			}
+			if redacted != nil {
+				redacted[i] = item
+			}
+		}
+		return redacted, redacted != nil
+
+	case reflect.Struct:
+		m := make(map[string]interface{}, v.NumField())
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if f.PkgPath != "" {
+				continue
+			}
+			name := f.Name
+			if tag := f.Tag.Get("json"); tag != "" {
+				if tag == "-" {
+					continue
+				}
+				if tagName := strings.Split(tag, ",")[0]; tagName != "" {
+					name = tagName
+				}
+			}
+			m[name] = valueInterface(v.Field(i))
+		}
+		return r.redactFields(m, depth)
+	}
+	return nil, false
+}
+
+func valueInterface(v reflect.Value) interface{} {
+	if !v.IsValid() || !v.CanInterface() {
+		return nil
+	}
+	return v.Interface()
+}
+
+// redactTyped returns the typed fields, or a copy of them if any value has to be masked
+func (r *compiledRedactor) redactTyped(fields []Field) ([]Field, bool) {
+	var redacted []Field
+	for i, field := range fields {
+		replacement, changed := r.redactTypedField(field)
+		if !changed {
+			continue
+		}
+		if redacted == nil {
+			redacted = make([]Field, len(fields))
+			copy(redacted, fields)
+		}
+		redacted[i] = replacement
+	}
+	if redacted == nil {
+		return fields, false
+	}
+	return redacted, true
+}
+
+func (r *compiledRedactor) redactTypedField(field Field) (Field, bool) {
+	if mask := r.keyMask(field.Key); mask != nil {
+		return String(field.Key, mask(field.text())), true
+	}
+	switch field.Type {
+	case StringType:
+		if s, changed := r.redactString(field.String); changed {
+			return String(field.Key, s), true
+		}
+	case ErrorType, AnyType:
+		if value, changed := r.redactValue(field.Interface, 0); changed {
+			return Any(field.Key, value), true
+		}
+	}
+	return field, false
+}
@@ -0,0 +1,374 @@
+package logrus
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const (
+	backupTimeFormat = "2006-01-02T15-04-05.000"
+	compressSuffix   = ".gz"
+)
+
+// RotationPolicy configures when a RotatingFileWriter rotates the file and which backups it keeps.
+type RotationPolicy struct {
+	// MaxSize is the maximum size of the file in bytes before it gets rotated. Zero disables
+	// the size based rotation.
+	MaxSize int64
+
+	// Interval rotates the file on the time boundaries which are multiples of the interval,
+	// e.g. 24 * time.Hour rotates the file at midnight UTC. Zero disables the time based rotation.
+	Interval time.Duration
+
+	// MaxBackups is the maximum number of rotated files to keep. Zero keeps all of them.
+	MaxBackups int
+
+	// MaxAge is the maximum time to keep the rotated files for. Zero keeps all of them.
+	MaxAge time.Duration
+
+	// Compress gzips the rotated files in the background.
+	Compress bool
+}
+
+// RotatingFileWriter is an io.Writer which writes to a file and rotates it based on its size
+// and/or a time interval. The rotated files are renamed to `name-<timestamp>.ext` in the same
+// directory.
+//
+// Rotation only ever happens between two Write calls. Logger writes every entry with a single
+// Write call while holding its mutex, so setting a RotatingFileWriter as the Logger's output
+// never splits an entry across two files:
+//
+//	w, err := logrus.NewRotatingFileWriter("/var/log/app.log", logrus.RotationPolicy{
+//		MaxSize:    100 << 20,
+//		MaxBackups: 5,
+//		Compress:   true,
+//	})
+//	if err != nil {
+//		...
+//	}
+//	logger.SetOutput(w)
+type RotatingFileWriter struct {
+	filename string
+	policy   RotationPolicy
+
+	mux          sync.Mutex
+	file         *os.File
+	size         int64
+	nextRotation time.Time
+
+	// cleanup is used to wait for the background compression and removal of the backups,
+	// which are serialised by cleanupMux
+	cleanup    sync.WaitGroup
+	cleanupMux sync.Mutex
+	signals    chan os.Signal
+	stopped    chan struct{}
+
+	// now and rename are replaced by the tests
+	now    func() time.Time
+	rename func(oldpath, newpath string) error
+}
+
+// NewRotatingFileWriter opens (or creates) the file for appending and returns a writer which
+// rotates it according to the policy.
+func NewRotatingFileWriter(filename string, policy RotationPolicy) (*RotatingFileWriter, error) {
+	w := &RotatingFileWriter{
+		filename: filename,
+		policy:   policy,
+		now:      time.Now,
+		rename:   os.Rename,
+	}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// Write writes p to the file, rotating the file first if p would exceed MaxSize or the
+// current time interval is over.
+func (w *RotatingFileWriter) Write(p []byte) (int, error) {
+	w.mux.Lock()
+	defer w.mux.Unlock()
+
+	if w.file == nil {
+		return 0, fmt.Errorf("write %s: the rotating file writer is closed", w.filename)
+	}
+
+	if w.shouldRotate(int64(len(p))) {
+		if err := w.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+// Rotate rotates the file immediately.
+func (w *RotatingFileWriter) Rotate() error {
+	w.mux.Lock()
+	defer w.mux.Unlock()
+	return w.rotate()
+}
+
+// Reopen closes and reopens the file, without rotating it. This is useful when the file has
+// been moved by an external tool, like logrotate without copytruncate.
+func (w *RotatingFileWriter) Reopen() error {
+	w.mux.Lock()
+	defer w.mux.Unlock()
+	if err := w.closeFile(); err != nil {
+		return err
+	}
+	return w.open()
+}
+
+// ReopenOnSignal reopens the file every time the process receives one of the signals.
+// SIGHUP is used if no signal is specified.
+func (w *RotatingFileWriter) ReopenOnSignal(signals ...os.Signal) {
+	if len(signals) == 0 {
+		signals = []os.Signal{syscall.SIGHUP}
+	}
+
+	w.mux.Lock()
+	defer w.mux.Unlock()
+	if w.signals != nil {
+		signal.Stop(w.signals)
+		close(w.stopped)
+	}
+	w.signals = make(chan os.Signal, 1)
+	w.stopped = make(chan struct{})
+	signal.Notify(w.signals, signals...)
+
+	go func(received <-chan os.Signal, stopped <-chan struct{}) {
+		for {
+			select {
+			case <-received:
+				if err := w.Reopen(); err != nil {
+					fmt.Fprintf(os.Stderr, "Failed to reopen the log file, %v\n", err)
+				}
+			case <-stopped:
+				return
+			}
+		}
+	}(w.signals, w.stopped)
+}
+
+// Close closes the file and waits for the background compression of the backups to finish.
+func (w *RotatingFileWriter) Close() error {
+	w.mux.Lock()
+	if w.signals != nil {
+		signal.Stop(w.signals)
+		close(w.stopped)
+		w.signals = nil
+	}
+	err := w.closeFile()
+	w.mux.Unlock()
+
+	w.cleanup.Wait()
+	return err
+}
+
+func (w *RotatingFileWriter) shouldRotate(n int64) bool {
+	if w.policy.MaxSize > 0 && w.size > 0 && w.size+n > w.policy.MaxSize {
+		return true
+	}
+	return w.policy.Interval > 0 && !w.now().Before(w.nextRotation)
+}
+
+func (w *RotatingFileWriter) open() error {
+	if err := os.MkdirAll(filepath.Dir(w.filename), 0755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(w.filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	w.file = file
+	w.size = info.Size()
+	if w.policy.Interval > 0 {
+		w.nextRotation = w.now().Truncate(w.policy.Interval).Add(w.policy.Interval)
+	}
+	return nil
+}
+
+func (w *RotatingFileWriter) closeFile() error {
+	if w.file == nil {
+		return nil
+	}
+	err := w.file.Close()
+	w.file = nil
+	return err
+}
+
+// rotate must be called with w.mux held. If the file can't be rotated, the current file is
+// reopened, so that a transient error doesn't stop the logging for good.
+func (w *RotatingFileWriter) rotate() error {
+	if err := w.closeFile(); err != nil {
+		return w.reopenAfter(err)
+	}
+
+	now := w.now()
+	backup := w.backupName(now)
+	if err := w.rename(w.filename, backup); err != nil && !os.IsNotExist(err) {
+		return w.reopenAfter(err)
+	}
+
+	if err := w.open(); err != nil {
+		return err
+	}
+
+	w.cleanup.Add(1)
+	go func() {
+		defer w.cleanup.Done()
+		w.processBackups(backup, now)
+	}()
+	return nil
+}
+
+// reopenAfter reopens the file for appending after a failed rotation and returns the error of
+// the rotation
+func (w *RotatingFileWriter) reopenAfter(err error) error {
+	if w.file == nil {
+		if openErr := w.open(); openErr != nil {
+			return fmt.Errorf("rotate %s: %v, reopen: %v", w.filename, err, openErr)
+		}
+	}
+	return fmt.Errorf("rotate %s: %v", w.filename, err)
+}
+
+func (w *RotatingFileWriter) backupName(t time.Time) string {
+	dir, prefix, ext := w.nameParts()
+	name := filepath.Join(dir, prefix+t.UTC().Format(backupTimeFormat)+ext)
+	for i := 1; fileExists(name) || fileExists(name+compressSuffix); i++ {
+		name = filepath.Join(dir, fmt.Sprintf("%s%s.%d%s", prefix, t.UTC().Format(backupTimeFormat), i, ext))
+	}
+	return name
+}
+
+// nameParts splits the file name into the directory, the prefix of the backups and the extension
+func (w *RotatingFileWriter) nameParts() (string, string, string) {
+	dir, base := filepath.Split(w.filename)
+	ext := filepath.Ext(base)
+	return dir, strings.TrimSuffix(base, ext) + "-", ext
+}
+
+type backupFile struct {
+	path string
+	time time.Time
+}
+
+// processBackups compresses the new backup and removes the backups which are no longer needed
+func (w *RotatingFileWriter) processBackups(backup string, now time.Time) {
+	w.cleanupMux.Lock()
+	defer w.cleanupMux.Unlock()
+
+	if w.policy.Compress {
+		if err := compressFile(backup); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to compress the log file, %v\n", err)
+		}
+	}
+
+	if w.policy.MaxBackups <= 0 && w.policy.MaxAge <= 0 {
+		return
+	}
+
+	backups, err := w.backups()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to list the log backups, %v\n", err)
+		return
+	}
+
+	cutoff := now.Add(-w.policy.MaxAge)
+	for i, b := range backups {
+		expired := w.policy.MaxAge > 0 && b.time.Before(cutoff)
+		if (w.policy.MaxBackups > 0 && i >= w.policy.MaxBackups) || expired {
+			if err := os.Remove(b.path); err != nil && !os.IsNotExist(err) {
+				fmt.Fprintf(os.Stderr, "Failed to remove the log backup, %v\n", err)
+			}
+		}
+	}
+}
+
+// backups returns the rotated files, newest first
+func (w *RotatingFileWriter) backups() ([]backupFile, error) {
+	dir, prefix, ext := w.nameParts()
+	if dir == "" {
+		dir = "."
+	}
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var backups []backupFile
+	for _, f := range files {
+		name := strings.TrimSuffix(f.Name(), compressSuffix)
+		if f.IsDir() || !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, ext) {
+			continue
+		}
+		stamp := strings.TrimSuffix(strings.TrimPrefix(name, prefix), ext)
+		if len(stamp) < len(backupTimeFormat) {
+			continue
+		}
+		t, err := time.Parse(backupTimeFormat, stamp[:len(backupTimeFormat)])
+		if err != nil {
+			continue
+		}
+		backups = append(backups, backupFile{path: filepath.Join(dir, f.Name()), time: t})
+	}
+
+	sort.SliceStable(backups, func(i, j int) bool {
+		if backups[i].time.Equal(backups[j].time) {
+			return backups[i].path > backups[j].path
+		}
+		return backups[i].time.After(backups[j].time)
+	})
+	return backups, nil
+}
+
+func compressFile(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(path+compressSuffix, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+
+	gz := gzip.NewWriter(dst)
+	if _, err = io.Copy(gz, src); err == nil {
+		err = gz.Close()
+	}
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path + compressSuffix)
+		return err
+	}
+
+	src.Close()
+	return os.Remove(path)
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
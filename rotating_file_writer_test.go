@@ -0,0 +1,217 @@
+package logrus
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRotatingFileWriter(t *testing.T, policy RotationPolicy) (*RotatingFileWriter, string) {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "rotating_file_writer")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	w, err := NewRotatingFileWriter(filepath.Join(dir, "app.log"), policy)
+	require.NoError(t, err)
+	return w, dir
+}
+
+// fakeClock makes the writer use the returned time, advancing it by a millisecond on every call so
+// the backups get distinct names
+func fakeClock(w *RotatingFileWriter, start time.Time) *time.Time {
+	current := start
+	w.now = func() time.Time {
+		current = current.Add(time.Millisecond)
+		return current
+	}
+	return &current
+}
+
+func listDir(t *testing.T, dir string) []string {
+	t.Helper()
+	files, err := ioutil.ReadDir(dir)
+	require.NoError(t, err)
+	var names []string
+	for _, f := range files {
+		names = append(names, f.Name())
+	}
+	sort.Strings(names)
+	return names
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	return string(data)
+}
+
+func TestRotatingFileWriterRotatesOnSize(t *testing.T) {
+	w, dir := newTestRotatingFileWriter(t, RotationPolicy{MaxSize: 10})
+	fakeClock(w, time.Date(2018, 3, 8, 10, 0, 0, 0, time.UTC))
+
+	for _, line := range []string{"line 1\n", "line 2\n", "line 3\n"} {
+		_, err := w.Write([]byte(line))
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+
+	assert.Equal(t, []string{
+		"app-2018-03-08T10-00-00.001.log",
+		"app-2018-03-08T10-00-00.002.log",
+		"app.log",
+	}, listDir(t, dir))
+	assert.Equal(t, "line 1\n", readFile(t, filepath.Join(dir, "app-2018-03-08T10-00-00.001.log")))
+	assert.Equal(t, "line 2\n", readFile(t, filepath.Join(dir, "app-2018-03-08T10-00-00.002.log")))
+	assert.Equal(t, "line 3\n", readFile(t, filepath.Join(dir, "app.log")))
+}
+
+func TestRotatingFileWriterDoesNotSplitLargeWrites(t *testing.T) {
+	w, dir := newTestRotatingFileWriter(t, RotationPolicy{MaxSize: 4})
+
+	_, err := w.Write([]byte("a line longer than the maximum size\n"))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	assert.Equal(t, []string{"app.log"}, listDir(t, dir))
+}
+
+func TestRotatingFileWriterRotatesOnInterval(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rotating_file_writer")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	current := time.Date(2018, 3, 8, 10, 59, 0, 0, time.UTC)
+	w := &RotatingFileWriter{
+		filename: filepath.Join(dir, "app.log"),
+		policy:   RotationPolicy{Interval: time.Hour},
+		now:      func() time.Time { return current },
+		rename:   os.Rename,
+	}
+	require.NoError(t, w.open())
+
+	w.Write([]byte("before\n"))
+	current = time.Date(2018, 3, 8, 11, 0, 0, 0, time.UTC)
+	w.Write([]byte("after\n"))
+	require.NoError(t, w.Close())
+
+	assert.Equal(t, []string{"app-2018-03-08T11-00-00.000.log", "app.log"}, listDir(t, dir))
+	assert.Equal(t, "before\n", readFile(t, filepath.Join(dir, "app-2018-03-08T11-00-00.000.log")))
+	assert.Equal(t, "after\n", readFile(t, filepath.Join(dir, "app.log")))
+}
+
+func TestRotatingFileWriterKeepsMaxBackups(t *testing.T) {
+	w, dir := newTestRotatingFileWriter(t, RotationPolicy{MaxBackups: 2})
+	fakeClock(w, time.Date(2018, 3, 8, 10, 0, 0, 0, time.UTC))
+
+	for i := 0; i < 4; i++ {
+		w.Write([]byte("line\n"))
+		require.NoError(t, w.Rotate())
+	}
+	require.NoError(t, w.Close())
+
+	assert.Equal(t, []string{
+		"app-2018-03-08T10-00-00.003.log",
+		"app-2018-03-08T10-00-00.004.log",
+		"app.log",
+	}, listDir(t, dir))
+}
+
+func TestRotatingFileWriterRemovesExpiredBackups(t *testing.T) {
+	w, dir := newTestRotatingFileWriter(t, RotationPolicy{MaxAge: time.Hour})
+	current := fakeClock(w, time.Date(2018, 3, 8, 10, 0, 0, 0, time.UTC))
+
+	require.NoError(t, w.Rotate())
+	w.Close()
+	*current = current.Add(2 * time.Hour)
+	require.NoError(t, w.open())
+	require.NoError(t, w.Rotate())
+	require.NoError(t, w.Close())
+
+	assert.Equal(t, []string{"app-2018-03-08T12-00-00.002.log", "app.log"}, listDir(t, dir))
+}
+
+func TestRotatingFileWriterCompressesBackups(t *testing.T) {
+	w, dir := newTestRotatingFileWriter(t, RotationPolicy{Compress: true})
+	fakeClock(w, time.Date(2018, 3, 8, 10, 0, 0, 0, time.UTC))
+
+	w.Write([]byte("compress me\n"))
+	require.NoError(t, w.Rotate())
+	require.NoError(t, w.Close())
+
+	assert.Equal(t, []string{"app-2018-03-08T10-00-00.001.log.gz", "app.log"}, listDir(t, dir))
+
+	f, err := os.Open(filepath.Join(dir, "app-2018-03-08T10-00-00.001.log.gz"))
+	require.NoError(t, err)
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	require.NoError(t, err)
+	data, err := ioutil.ReadAll(gz)
+	require.NoError(t, err)
+	assert.Equal(t, "compress me\n", string(data))
+}
+
+func TestRotatingFileWriterAsLoggerOutput(t *testing.T) {
+	w, dir := newTestRotatingFileWriter(t, RotationPolicy{MaxSize: 64})
+
+	logger := New(InfoLevel)
+	logger.SetOutput(w)
+	logger.SetFormatter(&TextFormatter{DisableColors: true, DisableTimestamp: true})
+	for i := 0; i < 20; i++ {
+		logger.Infof("entry number %d", i)
+	}
+	require.NoError(t, w.Close())
+
+	var entries int
+	for _, name := range listDir(t, dir) {
+		content := readFile(t, filepath.Join(dir, name))
+		assert.True(t, strings.HasSuffix(content, "\n"), "partial entry in %s: %q", name, content)
+		for _, line := range strings.Split(strings.TrimSpace(content), "\n") {
+			assert.True(t, strings.HasPrefix(line, "level=info msg=\"entry number "), line)
+			entries++
+		}
+	}
+	assert.Equal(t, 20, entries)
+}
+
+func TestRotatingFileWriterAfterClose(t *testing.T) {
+	w, _ := newTestRotatingFileWriter(t, RotationPolicy{})
+	require.NoError(t, w.Close())
+
+	_, err := w.Write([]byte("line\n"))
+	assert.Error(t, err)
+}
+
+func TestRotatingFileWriterKeepsWritingAfterFailedRotation(t *testing.T) {
+	w, dir := newTestRotatingFileWriter(t, RotationPolicy{MaxSize: 10})
+	fakeClock(w, time.Date(2018, 3, 8, 10, 0, 0, 0, time.UTC))
+	renameErr := &os.LinkError{Op: "rename", Err: syscall.EXDEV}
+	w.rename = func(string, string) error { return renameErr }
+
+	_, err := w.Write([]byte("first\n"))
+	require.NoError(t, err)
+	_, err = w.Write([]byte("second\n"))
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "cross-device")
+
+	// The rotation is retried once the error is gone
+	w.rename = os.Rename
+	_, err = w.Write([]byte("third\n"))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	names := listDir(t, dir)
+	require.Len(t, names, 2)
+	assert.Equal(t, "first\n", readFile(t, filepath.Join(dir, names[0])))
+	assert.Equal(t, "third\n", readFile(t, filepath.Join(dir, "app.log")))
+}
@@ -0,0 +1,34 @@
+//go:build !windows
+// +build !windows
+
+package logrus
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRotatingFileWriterReopensOnSignal(t *testing.T) {
+	w, dir := newTestRotatingFileWriter(t, RotationPolicy{})
+	defer w.Close()
+	w.ReopenOnSignal(syscall.SIGHUP)
+
+	w.Write([]byte("before\n"))
+	require.NoError(t, os.Rename(filepath.Join(dir, "app.log"), filepath.Join(dir, "moved.log")))
+	require.NoError(t, syscall.Kill(os.Getpid(), syscall.SIGHUP))
+
+	assert.Eventually(t, func() bool {
+		_, err := os.Stat(filepath.Join(dir, "app.log"))
+		return err == nil
+	}, time.Second, 10*time.Millisecond)
+
+	w.Write([]byte("after\n"))
+	assert.Equal(t, "before\n", readFile(t, filepath.Join(dir, "moved.log")))
+	assert.Equal(t, "after\n", readFile(t, filepath.Join(dir, "app.log")))
+}
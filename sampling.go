@@ -0,0 +1,330 @@
+package logrus
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// defaultSamplingInterval is used when SamplingPolicy.Interval is not set.
+const defaultSamplingInterval = time.Second
+
+// maxSamplingKeys bounds the number of keys tracked by the rate limiter
+const maxSamplingKeys = 4096
+
+// samplingSummaryMessage is the message of the entry reporting the suppressed entries.
+const samplingSummaryMessage = "log entries suppressed by sampling"
+
+// SamplingPolicy limits the number of entries logged at a level. The entries are grouped by
+// their message template (the format passed to Writef, or the first argument of Write if it's
+// a string) or by the value of KeyField, and each group is sampled separately.
+type SamplingPolicy struct {
+	// First is the number of entries logged per group in every interval before the sampling kicks in.
+	First int
+
+	// Thereafter logs every Thereafter-th entry of the group after the first ones, until the end
+	// of the interval. Zero suppresses all the entries after the first ones.
+	Thereafter int
+
+	// Interval is the period after which the counters are reset. The default is one second.
+	Interval time.Duration
+
+	// Rate is the number of entries per second allowed for every group by the token bucket
+	// rate limiter. Zero disables the rate limiting.
+	Rate float64
+
+	// Burst is the maximum number of entries the rate limiter allows in a burst. It defaults to one.
+	Burst int
+
+	// KeyField groups the entries by the value of this field, of any type, instead of the message
+	// template. The entries without the field are grouped together.
+	KeyField string
+}
+
+// SamplingOptions configures the sampling of a Logger.
+type SamplingOptions struct {
+	// Levels holds the sampling policy of every sampled level. The entries of the levels which
+	// are not in the map are never suppressed.
+	Levels map[Level]SamplingPolicy
+
+	// SummaryInterval is the period of the summary entries, reporting how many entries were
+	// suppressed at each level since the previous summary. The summary is logged at warning
+	// level, once the interval has elapsed, and only if some entries were suppressed. The
+	// pending counts are also logged when the sampling is replaced with SetSampling and before
+	// the Logger exits. Zero disables the summary.
+	SummaryInterval time.Duration
+}
+
+type samplingKey struct {
+	level Level
+	key   string
+}
+
+type samplingCounter struct {
+	count   uint64
+	resetAt time.Time
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+type sampler struct {
+	opts SamplingOptions
+
+	mux         sync.Mutex
+	counters    map[samplingKey]*samplingCounter
+	buckets     map[samplingKey]*tokenBucket
+	suppressed  map[Level]uint64
+	nextSummary time.Time
+	// timer logs the summary once the interval has elapsed, if no sampled entry does it before
+	timer  *time.Timer
+	closed bool
+
+	// summarize logs the summary of the suppressed entries
+	summarize func(suppressed map[Level]uint64)
+	// now is replaced by the tests
+	now func() time.Time
+}
+
+func newSampler(opts SamplingOptions, summarize func(map[Level]uint64)) *sampler {
+	s := &sampler{
+		opts:       opts,
+		counters:   make(map[samplingKey]*samplingCounter),
+		buckets:    make(map[samplingKey]*tokenBucket),
+		suppressed: make(map[Level]uint64),
+		summarize:  summarize,
+		now:        time.Now,
+	}
+	if opts.SummaryInterval > 0 {
+		s.nextSummary = s.now().Add(opts.SummaryInterval)
+	}
+	return s
+}
+
+// allow decides whether the entry has to be logged. It also returns the suppressed counts
+// when it's time to log the summary.
+func (s *sampler) allow(level Level, policy SamplingPolicy, key string) (bool, map[Level]uint64) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	now := s.now()
+	k := samplingKey{level: level, key: key}
+	allowed := s.sample(k, policy, now) && s.limit(k, policy, now)
+	if !allowed {
+		s.suppressed[level]++
+		s.scheduleSummary(now)
+	}
+
+	var summary map[Level]uint64
+	if s.opts.SummaryInterval > 0 && !now.Before(s.nextSummary) {
+		summary = s.takeSummary(now)
+	}
+	return allowed, summary
+}
+
+// scheduleSummary starts the timer logging the summary, so the suppressed entries are reported
+// even if no other sampled entry is logged. It must be called with s.mux held.
+func (s *sampler) scheduleSummary(now time.Time) {
+	if s.opts.SummaryInterval <= 0 || s.timer != nil || s.closed {
+		return
+	}
+	delay := s.nextSummary.Sub(now)
+	if delay < 0 {
+		delay = 0
+	}
+	s.timer = time.AfterFunc(delay, s.flush)
+}
+
+// takeSummary returns the suppressed counts, or nil if no entry was suppressed, and starts a new
+// summary interval. It must be called with s.mux held.
+func (s *sampler) takeSummary(now time.Time) map[Level]uint64 {
+	s.nextSummary = now.Add(s.opts.SummaryInterval)
+	if s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+	if len(s.suppressed) == 0 {
+		return nil
+	}
+	summary := s.suppressed
+	s.suppressed = make(map[Level]uint64)
+	return summary
+}
+
+// flush logs the summary of the entries suppressed so far
+func (s *sampler) flush() {
+	if s.opts.SummaryInterval <= 0 {
+		return
+	}
+	s.mux.Lock()
+	summary := s.takeSummary(s.now())
+	s.mux.Unlock()
+	if summary != nil {
+		s.summarize(summary)
+	}
+}
+
+// close logs the pending summary and stops the timer for good
+func (s *sampler) close() {
+	s.mux.Lock()
+	s.closed = true
+	s.mux.Unlock()
+	s.flush()
+}
+
+func (s *sampler) sample(k samplingKey, policy SamplingPolicy, now time.Time) bool {
+	if policy.First <= 0 && policy.Thereafter <= 0 {
+		return true
+	}
+	interval := policy.Interval
+	if interval <= 0 {
+		interval = defaultSamplingInterval
+	}
+
+	counter, ok := s.counters[k]
+	if !ok || !now.Before(counter.resetAt) {
+		if !ok && len(s.counters) >= maxSamplingKeys {
+			s.dropExpiredCounters(now)
+		}
+		counter = &samplingCounter{resetAt: now.Add(interval)}
+		s.counters[k] = counter
+	}
+	counter.count++
+
+	first := uint64(policy.First)
+	if counter.count <= first {
+		return true
+	}
+	return policy.Thereafter > 0 && (counter.count-first)%uint64(policy.Thereafter) == 0
+}
+
+func (s *sampler) limit(k samplingKey, policy SamplingPolicy, now time.Time) bool {
+	if policy.Rate <= 0 {
+		return true
+	}
+	burst := float64(policy.Burst)
+	if burst < 1 {
+		burst = 1
+	}
+
+	bucket, ok := s.buckets[k]
+	if !ok {
+		if len(s.buckets) >= maxSamplingKeys {
+			s.buckets = make(map[samplingKey]*tokenBucket)
+		}
+		bucket = &tokenBucket{tokens: burst, last: now}
+		s.buckets[k] = bucket
+	}
+
+	bucket.tokens += now.Sub(bucket.last).Seconds() * policy.Rate
+	if bucket.tokens > burst {
+		bucket.tokens = burst
+	}
+	bucket.last = now
+
+	if bucket.tokens < 1 {
+		return false
+	}
+	bucket.tokens--
+	return true
+}
+
+func (s *sampler) dropExpiredCounters(now time.Time) {
+	for k, counter := range s.counters {
+		if !now.Before(counter.resetAt) {
+			delete(s.counters, k)
+		}
+	}
+}
+
+// SetSampling enables the sampling of the entries. Sampling decides whether an entry gets
+// logged before its message is built, so the suppressed entries don't allocate.
+// Passing empty options disables the sampling.
+// The summary of the entries suppressed by the previous sampling is logged.
+func (logger *Logger) SetSampling(opts SamplingOptions) {
+	var s *sampler
+	if len(opts.Levels) > 0 {
+		levels := make(map[Level]SamplingPolicy, len(opts.Levels))
+		for level, policy := range opts.Levels {
+			levels[level] = policy
+		}
+		opts.Levels = levels
+		s = newSampler(opts, logger.logSamplingSummary)
+	}
+
+	logger.mux.Lock()
+	old, _ := logger.sampler.Load().(*sampler)
+	logger.sampler.Store(s)
+	logger.mux.Unlock()
+	if old != nil {
+		old.close()
+	}
+}
+
+// flushSampling logs the summary of the entries suppressed so far
+func (logger *Logger) flushSampling() {
+	if s, _ := logger.sampler.Load().(*sampler); s != nil {
+		s.flush()
+	}
+}
+
+// sample returns true if the entry has to be logged.
+func (logger *Logger) sample(level Level, data Fields, typed []Field, mode formatMode, format string, args []interface{}) bool {
+	s, _ := logger.sampler.Load().(*sampler)
+	if s == nil {
+		return true
+	}
+	policy, ok := s.opts.Levels[level]
+	if !ok {
+		return true
+	}
+
+	allowed, summary := s.allow(level, policy, samplingKeyOf(policy, data, typed, mode, format, args))
+	if summary != nil {
+		logger.logSamplingSummary(summary)
+	}
+	return allowed
+}
+
+// samplingKeyOf returns the key used to group the entries, without building the message
+func samplingKeyOf(policy SamplingPolicy, data Fields, typed []Field, mode formatMode, format string, args []interface{}) string {
+	if policy.KeyField != "" {
+		if i := typedFieldIndex(typed, policy.KeyField); i >= 0 {
+			return typed[i].text()
+		}
+		switch key := data[policy.KeyField].(type) {
+		case nil:
+			return ""
+		case string:
+			return key
+		default:
+			return fmt.Sprint(key)
+		}
+	}
+	if mode == formatted {
+		return format
+	}
+	if len(args) > 0 {
+		key, _ := args[0].(string)
+		return key
+	}
+	return ""
+}
+
+func (logger *Logger) logSamplingSummary(suppressed map[Level]uint64) {
+	if !logger.IsLevelEnabled(WarnLevel) {
+		return
+	}
+	fields := make(Fields, len(suppressed)+1)
+	var total uint64
+	for level, count := range suppressed {
+		fields["suppressed_"+level.String()] = count
+		total += count
+	}
+	fields["suppressed"] = total
+
+	entry := newLogEntry(logger, WarnLevel, fields)
+	entry.log(samplingSummaryMessage)
+}
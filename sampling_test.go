@@ -0,0 +1,253 @@
+package logrus
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newSampledLogger(opts SamplingOptions) (*Logger, *bytes.Buffer, *time.Time) {
+	var buffer bytes.Buffer
+	logger := New(DebugLevel)
+	logger.SetOutput(&buffer)
+	logger.SetFormatter(new(JSONFormatter))
+	logger.SetSampling(opts)
+
+	current := time.Date(2018, 3, 8, 10, 0, 0, 0, time.UTC)
+	s := logger.sampler.Load().(*sampler)
+	s.now = func() time.Time { return current }
+	s.nextSummary = current.Add(opts.SummaryInterval)
+	return logger, &buffer, &current
+}
+
+func decodeEntries(t *testing.T, buffer *bytes.Buffer) []Fields {
+	t.Helper()
+	var entries []Fields
+	for _, line := range strings.Split(strings.TrimSpace(buffer.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var fields Fields
+		require.NoError(t, json.Unmarshal([]byte(line), &fields))
+		entries = append(entries, fields)
+	}
+	return entries
+}
+
+func messages(entries []Fields) []string {
+	var result []string
+	for _, e := range entries {
+		result = append(result, e[messageKey].(string))
+	}
+	return result
+}
+
+func TestSamplingFirstThenEveryMth(t *testing.T) {
+	logger, buffer, _ := newSampledLogger(SamplingOptions{
+		Levels: map[Level]SamplingPolicy{WarnLevel: {First: 2, Thereafter: 3}},
+	})
+
+	for i := 1; i <= 10; i++ {
+		logger.Warningf("entry %d", i)
+	}
+
+	assert.Equal(t, []string{"entry 1", "entry 2", "entry 5", "entry 8"}, messages(decodeEntries(t, buffer)))
+}
+
+func TestSamplingResetsAfterTheInterval(t *testing.T) {
+	logger, buffer, current := newSampledLogger(SamplingOptions{
+		Levels: map[Level]SamplingPolicy{InfoLevel: {First: 1, Interval: time.Minute}},
+	})
+
+	logger.Info("tick")
+	logger.Info("tick")
+	*current = current.Add(time.Minute)
+	logger.Info("tick")
+
+	assert.Len(t, decodeEntries(t, buffer), 2)
+}
+
+func TestSamplingKeysByMessageTemplate(t *testing.T) {
+	logger, buffer, _ := newSampledLogger(SamplingOptions{
+		Levels: map[Level]SamplingPolicy{InfoLevel: {First: 1}},
+	})
+
+	logger.Infof("user %s logged in", "alice")
+	logger.Infof("user %s logged in", "bob")
+	logger.Infof("order %s shipped", "42")
+	logger.AsInfo().Write("plain")
+	logger.AsInfo().Write("plain")
+
+	assert.Equal(t, []string{"user alice logged in", "order 42 shipped", "plain"}, messages(decodeEntries(t, buffer)))
+}
+
+func TestSamplingKeysByField(t *testing.T) {
+	logger, buffer, _ := newSampledLogger(SamplingOptions{
+		Levels: map[Level]SamplingPolicy{InfoLevel: {First: 1, KeyField: "user"}},
+	})
+
+	logger.AsInfo().WithField("user", "alice").Write("first")
+	logger.AsInfo().WithField("user", "alice").Write("second")
+	logger.AsInfo().WithField("user", "bob").Write("third")
+
+	assert.Equal(t, []string{"first", "third"}, messages(decodeEntries(t, buffer)))
+}
+
+func TestSamplingKeysByNonStringField(t *testing.T) {
+	logger, buffer, _ := newSampledLogger(SamplingOptions{
+		Levels: map[Level]SamplingPolicy{InfoLevel: {First: 1, KeyField: "user"}},
+	})
+
+	logger.AsInfo().WithField("user", 1).Write("first")
+	logger.AsInfo().WithField("user", 1).Write("second")
+	logger.AsInfo().WithField("user", 2).Write("third")
+	logger.AsInfo().With(Int("user", 3)).Write("fourth")
+	logger.AsInfo().With(Int("user", 3)).Write("fifth")
+	logger.AsInfo().With(Int("user", 4)).Write("sixth")
+
+	assert.Equal(t, []string{"first", "third", "fourth", "sixth"}, messages(decodeEntries(t, buffer)))
+}
+
+func TestSamplingRateLimit(t *testing.T) {
+	logger, buffer, current := newSampledLogger(SamplingOptions{
+		Levels: map[Level]SamplingPolicy{ErrorLevel: {Rate: 2, Burst: 2}},
+	})
+
+	for i := 0; i < 5; i++ {
+		logger.Error("failure")
+	}
+	assert.Len(t, decodeEntries(t, buffer), 2)
+
+	*current = current.Add(500 * time.Millisecond)
+	logger.Error("failure")
+	logger.Error("failure")
+	assert.Len(t, decodeEntries(t, buffer), 3)
+}
+
+func TestSamplingDoesNotAffectOtherLevels(t *testing.T) {
+	logger, buffer, _ := newSampledLogger(SamplingOptions{
+		Levels: map[Level]SamplingPolicy{DebugLevel: {First: 1}},
+	})
+
+	for i := 0; i < 3; i++ {
+		logger.Info("info")
+		logger.Debug("debug")
+	}
+
+	assert.Len(t, decodeEntries(t, buffer), 4)
+}
+
+func TestSamplingSummary(t *testing.T) {
+	logger, buffer, current := newSampledLogger(SamplingOptions{
+		Levels: map[Level]SamplingPolicy{
+			InfoLevel:  {First: 1},
+			DebugLevel: {First: 1},
+		},
+		SummaryInterval: 10 * time.Second,
+	})
+
+	for i := 0; i < 4; i++ {
+		logger.Info("info")
+	}
+	logger.Debug("debug")
+	logger.Debug("debug")
+	*current = current.Add(10 * time.Second)
+	logger.Debug("debug")
+
+	entries := decodeEntries(t, buffer)
+	require.Len(t, entries, 4)
+	summary := entries[2]
+	assert.Equal(t, samplingSummaryMessage, summary[messageKey])
+	assert.Equal(t, "warning", summary[levelKey])
+	assert.Equal(t, float64(4), summary["suppressed"])
+	assert.Equal(t, float64(3), summary["suppressed_info"])
+	assert.Equal(t, float64(1), summary["suppressed_debug"])
+}
+
+func TestSamplingSummaryAfterTheTrafficStops(t *testing.T) {
+	var out syncBuffer
+	logger := New(InfoLevel)
+	logger.SetOutput(&out)
+	logger.SetFormatter(new(JSONFormatter))
+	logger.SetSampling(SamplingOptions{
+		Levels:          map[Level]SamplingPolicy{InfoLevel: {First: 1}},
+		SummaryInterval: 20 * time.Millisecond,
+	})
+	defer logger.SetSampling(SamplingOptions{})
+
+	for i := 0; i < 3; i++ {
+		logger.Info("burst")
+	}
+
+	var entries []Fields
+	assert.Eventually(t, func() bool {
+		buffer := bytes.NewBuffer(out.Bytes())
+		entries = decodeEntries(t, buffer)
+		return len(entries) == 2
+	}, 5*time.Second, time.Millisecond)
+	assert.Equal(t, samplingSummaryMessage, entries[1][messageKey])
+	assert.Equal(t, float64(2), entries[1]["suppressed_info"])
+}
+
+func TestSamplingSummaryIsLoggedWhenReplaced(t *testing.T) {
+	logger, buffer, _ := newSampledLogger(SamplingOptions{
+		Levels:          map[Level]SamplingPolicy{InfoLevel: {First: 1}},
+		SummaryInterval: time.Hour,
+	})
+	logger.Info("info")
+	logger.Info("info")
+	logger.SetSampling(SamplingOptions{})
+
+	entries := decodeEntries(t, buffer)
+	require.Len(t, entries, 2)
+	assert.Equal(t, samplingSummaryMessage, entries[1][messageKey])
+	assert.Equal(t, float64(1), entries[1]["suppressed"])
+}
+
+func TestSamplingSummaryIsLoggedBeforeExiting(t *testing.T) {
+	logger, buffer, _ := newSampledLogger(SamplingOptions{
+		Levels:          map[Level]SamplingPolicy{InfoLevel: {First: 1}},
+		SummaryInterval: time.Hour,
+	})
+	defer logger.SetSampling(SamplingOptions{})
+	var code int
+	logger.SetExitOptions(ExitOptions{ExitFunc: func(c int) { code = c }})
+
+	logger.Info("info")
+	logger.Info("info")
+	logger.Fatal("bye")
+
+	assert.Equal(t, 1, code)
+	entries := decodeEntries(t, buffer)
+	require.Len(t, entries, 3)
+	assert.Equal(t, []string{"info", "bye", samplingSummaryMessage}, messages(entries))
+}
+
+func TestSamplingCanBeDisabled(t *testing.T) {
+	logger, buffer, _ := newSampledLogger(SamplingOptions{
+		Levels: map[Level]SamplingPolicy{InfoLevel: {First: 1}},
+	})
+	logger.SetSampling(SamplingOptions{})
+
+	logger.Info("info")
+	logger.Info("info")
+
+	assert.Len(t, decodeEntries(t, buffer), 2)
+}
+
+func TestSuppressedEntriesDoNotAllocate(t *testing.T) {
+	logger, _, _ := newSampledLogger(SamplingOptions{
+		Levels: map[Level]SamplingPolicy{WarnLevel: {First: 1}},
+	})
+	logger.Warningf("hot loop %s", "value")
+
+	allocs := testing.AllocsPerRun(100, func() {
+		logger.Warningf("hot loop %s", "value")
+	})
+	assert.Equal(t, float64(0), allocs)
+}
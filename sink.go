@@ -0,0 +1,184 @@
+package logrus
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sync/atomic"
+)
+
+// Sink is an additional output of a Logger, with its own formatter and level. For example, a
+// Logger can write colored text to stderr at Info level, while a sink writes JSON to a file at
+// Debug level.
+type Sink struct {
+	// Formatter formats the entries written to Out. The Logger's formatter is used if it's nil.
+	// The entries are formatted once per distinct formatter, so the sinks sharing a formatter
+	// (or sharing it with the Logger) get the same bytes.
+	Formatter Formatter
+
+	// Out receives the formatted entries. The writes are synchronized with the Logger's mutex,
+	// and go through the asynchronous buffer when the Logger is asynchronous (see SetAsync).
+	Out io.Writer
+
+	// Level is the least severe level written to the sink. Note that the zero value is
+	// PanicLevel.
+	Level Level
+
+	// Filter, if set, is called with the entries at or above Level, after the hooks have been
+	// fired. The entries for which it returns false are not written to the sink.
+	Filter func(*Entry) bool
+}
+
+// sinkSet is the immutable list of sinks of a Logger
+type sinkSet struct {
+	sinks []Sink
+}
+
+// AddSink adds an output to the Logger. The entries are written to the Logger's Out at the
+// Logger's level, and to every sink at the sink's level. The fields are only collected if the
+// entry is enabled for the Logger or any of its sinks, so a Debug sink makes WithField keep the
+// debug fields.
+func (logger *Logger) AddSink(sink Sink) {
+	logger.mux.Lock()
+	defer logger.mux.Unlock()
+	var sinks []Sink
+	if set := logger.sinkSet(); set != nil {
+		sinks = append(sinks, set.sinks...)
+	}
+	logger.storeSinks(append(sinks, sink))
+}
+
+// ReplaceSinks replaces the Logger's sinks and returns the old ones. Passing nil removes all the
+// sinks.
+func (logger *Logger) ReplaceSinks(sinks []Sink) []Sink {
+	logger.mux.Lock()
+	defer logger.mux.Unlock()
+	var old []Sink
+	if set := logger.sinkSet(); set != nil {
+		old = set.sinks
+	}
+	logger.storeSinks(append([]Sink(nil), sinks...))
+	return old
+}
+
+// storeSinks publishes the sinks and their most verbose level. It must be called with the
+// Logger's mutex held.
+func (logger *Logger) storeSinks(sinks []Sink) {
+	level := PanicLevel
+	for _, sink := range sinks {
+		if !level.Enables(sink.Level) {
+			level = sink.Level
+		}
+	}
+	if len(sinks) == 0 {
+		logger.sinks.Store((*sinkSet)(nil))
+	} else {
+		logger.sinks.Store(&sinkSet{sinks: sinks})
+	}
+	atomic.StoreUint32(&logger.sinkLevel, uint32(level))
+}
+
+func (logger *Logger) sinkSet() *sinkSet {
+	set, _ := logger.sinks.Load().(*sinkSet)
+	return set
+}
+
+// IsLevelEnabled returns true if the entries at the level are written to the Logger's Out or
+// to any of its sinks.
+func (logger *Logger) IsLevelEnabled(level Level) bool {
+	return logger.Level().Enables(level) || Level(atomic.LoadUint32(&logger.sinkLevel)).Enables(level)
+}
+
+// formatCache keeps the output of every formatter while an entry is written to the sinks, so
+// each distinct formatter formats it once
+type formatCache struct {
+	entry     *Entry
+	formatted []formattedEntry
+}
+
+type formattedEntry struct {
+	formatter  Formatter
+	serialized []byte
+	err        error
+}
+
+func (c *formatCache) format(formatter Formatter) ([]byte, error) {
+	for _, f := range c.formatted {
+		if sameFormatter(f.formatter, formatter) {
+			return f.serialized, f.err
+		}
+	}
+	serialized, err := formatter.Format(c.entry)
+	c.formatted = append(c.formatted, formattedEntry{formatter, serialized, err})
+	return serialized, err
+}
+
+// writeEntry formats the entry and writes it to Out, if it's enabled for the Logger's level,
+// and to the sinks enabled for it.
+func (logger *Logger) writeEntry(entry *Entry) {
+	set := logger.sinkSet()
+	if set == nil {
+		// The queue of the asynchronous output keeps the formatted entries, which can't be
+		// formatted into a reused buffer then
+		if formatter, ok := logger.formatter.(appendFormatter); ok && logger.asyncWriter() == nil {
+			logger.writeAppended(entry, formatter)
+			return
+		}
+		serialized, err := logger.formatter.Format(entry)
+		logger.writeFormatted(entry.Level, nil, serialized, err)
+		return
+	}
+
+	cache := formatCache{entry: entry, formatted: make([]formattedEntry, 0, len(set.sinks)+1)}
+	if logger.outputLevel(entry).Enables(entry.Level) {
+		serialized, err := cache.format(logger.formatter)
+		logger.writeFormatted(entry.Level, nil, serialized, err)
+	}
+	for _, sink := range set.sinks {
+		if !sink.Level.Enables(entry.Level) || sink.Out == nil || sink.Filter != nil && !sink.Filter(entry) {
+			continue
+		}
+		formatter := sink.Formatter
+		if formatter == nil {
+			formatter = logger.formatter
+		}
+		serialized, err := cache.format(formatter)
+		logger.writeFormatted(entry.Level, sink.Out, serialized, err)
+	}
+}
+
+// writeAppended formats the entry into a reused buffer and writes it to Out synchronously
+func (logger *Logger) writeAppended(entry *Entry, formatter appendFormatter) {
+	enc := newFormatEncoder()
+	defer enc.release()
+	serialized, err := formatter.appendFormat(enc.buf[:0], entry)
+	if err != nil {
+		logger.writeFormatted(entry.Level, nil, nil, err)
+		return
+	}
+	enc.buf = serialized
+	logger.writeSync(nil, serialized)
+}
+
+// writeFormatted writes the formatted entry to out, or to the Logger's Out if out is nil, unless
+// the formatter has failed.
+func (logger *Logger) writeFormatted(level Level, out io.Writer, serialized []byte, err error) {
+	if err != nil {
+		logger.mux.Lock()
+		fmt.Fprintf(os.Stderr, "Failed to obtain reader, %v\n", err)
+		logger.mux.Unlock()
+		return
+	}
+	logger.write(level, out, serialized)
+}
+
+// sameFormatter returns true if both formatters are the same value. The formatters of
+// incomparable types are never considered the same.
+func sameFormatter(a, b Formatter) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	t := reflect.TypeOf(a)
+	return t == reflect.TypeOf(b) && t.Comparable() && a == b
+}
@@ -0,0 +1,131 @@
+package logrus
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSinkLevels(t *testing.T) {
+	var text, file bytes.Buffer
+	logger := New(InfoLevel)
+	logger.SetOutput(&text)
+	logger.SetFormatter(&TextFormatter{DisableColors: true, DisableTimestamp: true})
+	logger.AddSink(Sink{Formatter: new(JSONFormatter), Out: &file, Level: DebugLevel})
+
+	logger.AsDebug().WithField("key", "value").Write("debug message")
+	assert.Empty(t, text.String())
+	var fields Fields
+	require.NoError(t, json.Unmarshal(file.Bytes(), &fields))
+	assert.Equal(t, "debug message", fields[messageKey])
+	assert.Equal(t, "value", fields["key"])
+
+	file.Reset()
+	logger.Info("info message")
+	assert.Equal(t, "level=info msg=\"info message\"\n", text.String())
+	assert.Contains(t, file.String(), `"msg":"info message"`)
+}
+
+func TestSinksDoNotShareThePrefixedFields(t *testing.T) {
+	var text, file bytes.Buffer
+	logger := New(InfoLevel)
+	logger.SetOutput(&text)
+	logger.SetFormatter(&TextFormatter{DisableColors: true, DisableTimestamp: true})
+	logger.AddSink(Sink{Formatter: &JSONFormatter{DisableTimestamp: true}, Out: &file, Level: InfoLevel})
+
+	entry := logger.WithField(levelKey, "x")
+	entry.Write("test")
+	entry.Write("again")
+
+	assert.Equal(t, "level=info msg=test fields.level=x\nlevel=info msg=again fields.level=x\n", text.String())
+	assert.Equal(t, `{"level":"info","msg":"test","fields.level":"x"}`+"\n"+
+		`{"level":"info","msg":"again","fields.level":"x"}`+"\n", file.String())
+	assert.Equal(t, Fields{levelKey: "x"}, entry.Data)
+}
+
+func TestSinksEnableTheFields(t *testing.T) {
+	logger := New(InfoLevel)
+	entry := logger.AsDebug()
+	assert.False(t, logger.IsLevelEnabled(DebugLevel))
+	assert.True(t, entry == entry.WithField("key", "value"))
+
+	logger.AddSink(Sink{Out: &bytes.Buffer{}, Level: DebugLevel})
+	assert.True(t, logger.IsLevelEnabled(DebugLevel))
+	assert.False(t, entry == entry.WithField("key", "value"))
+
+	old := logger.ReplaceSinks(nil)
+	assert.Len(t, old, 1)
+	assert.False(t, logger.IsLevelEnabled(DebugLevel))
+}
+
+type countingFormatter struct {
+	calls int32
+}
+
+func (f *countingFormatter) Format(entry *Entry) ([]byte, error) {
+	atomic.AddInt32(&f.calls, 1)
+	return []byte(entry.Message + "\n"), nil
+}
+
+func TestEntriesAreFormattedOncePerFormatter(t *testing.T) {
+	var out, first, second, other bytes.Buffer
+	shared := &countingFormatter{}
+	separate := &countingFormatter{}
+
+	logger := New(InfoLevel)
+	logger.SetOutput(&out)
+	logger.SetFormatter(shared)
+	logger.AddSink(Sink{Formatter: shared, Out: &first, Level: InfoLevel})
+	logger.AddSink(Sink{Out: &second, Level: InfoLevel})
+	logger.AddSink(Sink{Formatter: separate, Out: &other, Level: InfoLevel})
+
+	logger.Info("test")
+
+	assert.Equal(t, int32(1), shared.calls)
+	assert.Equal(t, int32(1), separate.calls)
+	for _, buffer := range []*bytes.Buffer{&out, &first, &second, &other} {
+		assert.Equal(t, "test\n", buffer.String())
+	}
+}
+
+func TestSinkFilter(t *testing.T) {
+	var audit bytes.Buffer
+	logger := New(InfoLevel)
+	logger.SetOutput(&bytes.Buffer{})
+	logger.AddSink(Sink{
+		Formatter: &countingFormatter{},
+		Out:       &audit,
+		Level:     InfoLevel,
+		Filter: func(entry *Entry) bool {
+			_, ok := entry.Data["audit"]
+			return ok
+		},
+	})
+
+	logger.Info("not audited")
+	logger.AsInfo().WithField("audit", true).Write("audited")
+	assert.Equal(t, "audited\n", audit.String())
+}
+
+func TestSinksWithAsyncLogger(t *testing.T) {
+	var out, sink bytes.Buffer
+	logger := New(InfoLevel)
+	logger.SetOutput(&out)
+	logger.SetFormatter(&countingFormatter{})
+	logger.AddSink(Sink{Out: &sink, Level: DebugLevel})
+	logger.SetAsync(AsyncOptions{})
+	defer logger.Close()
+
+	logger.Debug("debug")
+	logger.Info("info")
+	require.NoError(t, logger.Flush(context.Background()))
+
+	assert.Equal(t, "info\n", out.String())
+	assert.Equal(t, []string{"debug", "info"}, strings.Fields(sink.String()))
+}
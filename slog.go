@@ -0,0 +1,234 @@
+//go:build go1.21
+// +build go1.21
+
+package logrus
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"runtime"
+	"sort"
+)
+
+// slogLevelStep is the distance between two consecutive levels of log/slog
+const slogLevelStep = int(slog.LevelInfo - slog.LevelDebug)
+
+// SlogLevel returns the log/slog level of the level. The levels of logrus are mapped to the
+// levels of log/slog with the same name, the trace level to slog.LevelDebug-4, the fatal level
+// to slog.LevelError+4 and the panic level to slog.LevelError+8. The registered levels are
+// spread between the level they are registered after and the next one.
+func SlogLevel(level Level) slog.Level {
+	if level > TraceLevel {
+		levels := Levels()
+		for i, l := range levels {
+			if l != level {
+				continue
+			}
+			// The registered level is placed between the predefined levels around it
+			after, next := i-1, i+1
+			for levels[after] > TraceLevel {
+				after--
+			}
+			for next < len(levels) && levels[next] > TraceLevel {
+				next++
+			}
+			return predefinedSlogLevel(levels[after]) - slog.Level((i-after)*slogLevelStep/(next-after))
+		}
+	}
+	return predefinedSlogLevel(level)
+}
+
+func predefinedSlogLevel(level Level) slog.Level {
+	return slog.LevelInfo + slog.Level((int(InfoLevel)-int(level))*slogLevelStep)
+}
+
+// LevelFromSlog returns the level of the log/slog level, the least severe of the predefined and
+// registered levels whose SlogLevel is at or above it. The levels above slog.LevelError are
+// mapped to the error level, so that the entries of log/slog never exit nor panic, and the ones
+// below all the levels to the least severe one.
+func LevelFromSlog(level slog.Level) Level {
+	result := ErrorLevel
+	for _, l := range Levels() {
+		if l.Enables(ErrorLevel) && SlogLevel(l) >= level {
+			result = l
+		}
+	}
+	return result
+}
+
+// SlogHandler is a slog.Handler writing the records of log/slog with a Logger. The attributes of
+// the records are added to the entries as typed fields, the keys of the attributes in a group
+// prefixed with the name of the group and a dot, like "request.method". The levels are mapped
+// with LevelFromSlog.
+//
+//	slog.SetDefault(slog.New(logrus.NewSlogHandler(logger)))
+type SlogHandler struct {
+	entry *Entry
+	// group is the prefix of the keys added by WithGroup, ending with a dot
+	group string
+}
+
+// NewSlogHandler creates a slog.Handler writing the records with the Logger.
+func NewSlogHandler(logger *Logger) *SlogHandler {
+	return NewEntry(logger).SlogHandler()
+}
+
+// SlogHandler creates a slog.Handler writing the records with the entry, with its fields and its
+// component.
+func (entry *Entry) SlogHandler() *SlogHandler {
+	return &SlogHandler{entry: entry}
+}
+
+// Enabled returns true if the Logger writes the entries at the level.
+func (h *SlogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	entry := *h.entry
+	entry.Level = LevelFromSlog(level)
+	return entry.isEnabled()
+}
+
+// Handle writes the record with the Logger. The entry has the time of the record, and no time
+// if the record has none. The context is attached to the entry, for the context extractors of
+// the Logger.
+func (h *SlogHandler) Handle(ctx context.Context, record slog.Record) error {
+	entry := h.entry.clone(LevelFromSlog(record.Level), h.entry.Data)
+	entry.Time, entry.keepTime = record.Time, true
+	if ctx != nil {
+		entry.Context = ctx
+	}
+	if record.NumAttrs() > 0 {
+		typed := make([]Field, len(h.entry.Typed), len(h.entry.Typed)+record.NumAttrs())
+		copy(typed, h.entry.Typed)
+		record.Attrs(func(attr slog.Attr) bool {
+			typed = appendSlogAttr(typed, h.group, attr)
+			return true
+		})
+		entry.Typed = typed
+	}
+	if record.PC != 0 {
+		// The caller is the one which created the record, not the one calling Handle
+		frame, _ := runtime.CallersFrames([]uintptr{record.PC}).Next()
+		entry.writerCaller = &frame
+	}
+	entry.Write(record.Message)
+	return nil
+}
+
+// WithAttrs returns a handler adding the attributes to all the records.
+func (h *SlogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	typed := make([]Field, len(h.entry.Typed), len(h.entry.Typed)+len(attrs))
+	copy(typed, h.entry.Typed)
+	for _, attr := range attrs {
+		typed = appendSlogAttr(typed, h.group, attr)
+	}
+	entry := h.entry.clone(h.entry.Level, h.entry.Data)
+	entry.Typed = typed
+	return &SlogHandler{entry: entry, group: h.group}
+}
+
+// WithGroup returns a handler prefixing the keys of the attributes which are added later with
+// the name of the group.
+func (h *SlogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &SlogHandler{entry: h.entry, group: h.group + name + "."}
+}
+
+// appendSlogAttr appends the typed fields of the attribute, flattening the groups
+func appendSlogAttr(fields []Field, prefix string, attr slog.Attr) []Field {
+	attr.Value = attr.Value.Resolve()
+	if attr.Equal(slog.Attr{}) {
+		return fields
+	}
+
+	key := prefix + attr.Key
+	value := attr.Value
+	switch value.Kind() {
+	case slog.KindGroup:
+		if attr.Key != "" {
+			prefix = key + "."
+		}
+		for _, a := range value.Group() {
+			fields = appendSlogAttr(fields, prefix, a)
+		}
+		return fields
+	case slog.KindString:
+		return append(fields, String(key, value.String()))
+	case slog.KindInt64:
+		return append(fields, Int64(key, value.Int64()))
+	case slog.KindUint64:
+		if u := value.Uint64(); u <= math.MaxInt64 {
+			return append(fields, Int64(key, int64(u)))
+		}
+		return append(fields, Any(key, value.Uint64()))
+	case slog.KindFloat64:
+		return append(fields, Float64(key, value.Float64()))
+	case slog.KindBool:
+		return append(fields, Bool(key, value.Bool()))
+	case slog.KindDuration:
+		return append(fields, Duration(key, value.Duration()))
+	case slog.KindTime:
+		return append(fields, Time(key, value.Time()))
+	default:
+		return append(fields, Any(key, value.Any()))
+	}
+}
+
+// SlogHook is a Hook sending the entries to a slog.Handler, with their fields as attributes. The
+// levels are mapped with SlogLevel. The handler must not write to the Logger the hook is added
+// to, which would call the hook again.
+type SlogHook struct {
+	handler slog.Handler
+}
+
+// NewSlogHook creates a hook sending the entries to the handler.
+//
+//	logger.AddHook(logrus.NewSlogHook(slog.NewJSONHandler(os.Stdout, nil)))
+func NewSlogHook(handler slog.Handler) *SlogHook {
+	return &SlogHook{handler: handler}
+}
+
+// Levels returns all the levels: the handler decides which records are written.
+func (hook *SlogHook) Levels() []Level {
+	return Levels()
+}
+
+// Fire sends the entry to the handler.
+func (hook *SlogHook) Fire(entry *Entry) error {
+	ctx := entry.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	level := SlogLevel(entry.Level)
+	if !hook.handler.Enabled(ctx, level) {
+		return nil
+	}
+
+	var pc uintptr
+	if entry.Caller != nil {
+		// The program counters of the records are return addresses, one past the call
+		pc = entry.Caller.PC + 1
+	}
+	record := slog.NewRecord(entry.Time, level, entry.Message, pc)
+
+	keys := make([]string, 0, len(entry.Data))
+	for key := range entry.Data {
+		if typedFieldIndex(entry.Typed, key) < 0 {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		record.AddAttrs(slog.Any(key, entry.Data[key]))
+	}
+	for i, field := range entry.Typed {
+		if !isShadowed(entry.Typed, i) {
+			record.AddAttrs(slog.Any(field.Key, field.Value()))
+		}
+	}
+	return hook.handler.Handle(ctx, record)
+}
@@ -0,0 +1,154 @@
+//go:build go1.21
+// +build go1.21
+
+package logrus
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"testing"
+	"testing/slogtest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlogLevels(t *testing.T) {
+	levels := map[Level]slog.Level{
+		TraceLevel: slog.LevelDebug - 4,
+		DebugLevel: slog.LevelDebug,
+		InfoLevel:  slog.LevelInfo,
+		WarnLevel:  slog.LevelWarn,
+		ErrorLevel: slog.LevelError,
+		FatalLevel: slog.LevelError + 4,
+		PanicLevel: slog.LevelError + 8,
+	}
+	for level, slogLevel := range levels {
+		assert.Equal(t, slogLevel, SlogLevel(level), level.String())
+	}
+
+	assert.Equal(t, InfoLevel, LevelFromSlog(slog.LevelInfo))
+	assert.Equal(t, WarnLevel, LevelFromSlog(slog.LevelWarn))
+	assert.Equal(t, slog.LevelInfo+2, SlogLevel(noticeLevel))
+	assert.Equal(t, noticeLevel, LevelFromSlog(slog.LevelInfo+2))
+	assert.Equal(t, ErrorLevel, LevelFromSlog(slog.LevelError+8))
+	assert.Equal(t, TraceLevel, LevelFromSlog(slog.LevelDebug-8))
+	assert.Equal(t, DebugLevel, LevelFromSlog(slog.LevelDebug-1))
+	assert.Equal(t, noticeLevel, LevelFromSlog(slog.LevelInfo+1))
+	assert.Equal(t, WarnLevel, LevelFromSlog(slog.LevelInfo+3))
+	for level := slog.LevelDebug - 10; level <= slog.LevelError+10; level++ {
+		assert.NotEqual(t, "unknown", LevelFromSlog(level).String(), level)
+	}
+}
+
+func TestSlogHandlerConformance(t *testing.T) {
+	var buffer bytes.Buffer
+	logger := New(TraceLevel)
+	logger.SetOutput(&buffer)
+	logger.SetFormatter(&JSONFormatter{ExpandDottedKeys: true})
+
+	results := func() []map[string]interface{} {
+		var records []map[string]interface{}
+		decoder := json.NewDecoder(&buffer)
+		for decoder.More() {
+			var record map[string]interface{}
+			require.NoError(t, decoder.Decode(&record))
+			records = append(records, record)
+		}
+		return records
+	}
+	require.NoError(t, slogtest.TestHandler(NewSlogHandler(logger), results))
+}
+
+func TestSlogHandlerTime(t *testing.T) {
+	var buffer bytes.Buffer
+	logger := New(InfoLevel)
+	logger.SetOutput(&buffer)
+	logger.SetFormatter(&JSONFormatter{})
+	handler := NewSlogHandler(logger)
+
+	at := time.Date(2018, 3, 8, 10, 30, 0, 0, time.UTC)
+	require.NoError(t, handler.Handle(context.Background(), slog.NewRecord(at, slog.LevelInfo, "with time", 0)))
+	require.NoError(t, handler.Handle(context.Background(), slog.NewRecord(time.Time{}, slog.LevelInfo, "without time", 0)))
+
+	decoder := json.NewDecoder(&buffer)
+	var fields map[string]interface{}
+	require.NoError(t, decoder.Decode(&fields))
+	assert.Equal(t, "2018-03-08T10:30:00Z", fields["time"])
+	fields = nil
+	require.NoError(t, decoder.Decode(&fields))
+	assert.NotContains(t, fields, "time")
+	assert.Equal(t, "without time", fields["msg"])
+}
+
+func TestSlogHandler(t *testing.T) {
+	var buffer bytes.Buffer
+	logger := New(InfoLevel)
+	logger.SetOutput(&buffer)
+	logger.SetFormatter(&JSONFormatter{ExpandDottedKeys: true})
+
+	sl := slog.New(NewSlogHandler(logger)).With("service", "api").WithGroup("request")
+	sl.Warn("slow request", "method", "GET", slog.Int("status", 200), slog.Group("timing", slog.Duration("total", time.Second)))
+	sl.Debug("not written")
+
+	var fields map[string]interface{}
+	require.NoError(t, json.Unmarshal(buffer.Bytes(), &fields))
+	assert.Equal(t, "slow request", fields["msg"])
+	assert.Equal(t, "warning", fields["level"])
+	assert.Equal(t, "api", fields["service"])
+	assert.Equal(t, map[string]interface{}{
+		"method": "GET",
+		"status": float64(200),
+		"timing": map[string]interface{}{"total": float64(time.Second)},
+	}, fields["request"])
+}
+
+func TestSlogHandlerComponent(t *testing.T) {
+	var buffer bytes.Buffer
+	logger := New(InfoLevel)
+	logger.SetOutput(&buffer)
+	logger.SetComponentLevel("db", DebugLevel)
+
+	handler := logger.Named("db").SlogHandler()
+	assert.True(t, handler.Enabled(context.Background(), slog.LevelDebug))
+	assert.False(t, NewSlogHandler(logger).Enabled(context.Background(), slog.LevelDebug))
+
+	slog.New(handler).Debug("query")
+	assert.Contains(t, buffer.String(), "logger=db")
+}
+
+func TestSlogHandlerCaller(t *testing.T) {
+	var buffer bytes.Buffer
+	logger := New(InfoLevel)
+	logger.SetOutput(&buffer)
+	logger.SetFormatter(new(JSONFormatter))
+	logger.SetReportCaller(true)
+
+	slog.New(NewSlogHandler(logger)).Info("started")
+
+	var fields map[string]interface{}
+	require.NoError(t, json.Unmarshal(buffer.Bytes(), &fields))
+	assert.Equal(t, "github.com/xitonix/logrus.TestSlogHandlerCaller", fields["func"])
+}
+
+func TestSlogHook(t *testing.T) {
+	var buffer bytes.Buffer
+	logger := New(InfoLevel)
+	logger.SetOutput(new(bytes.Buffer))
+	logger.AddHook(NewSlogHook(slog.NewJSONHandler(&buffer, &slog.HandlerOptions{Level: slog.LevelWarn})))
+
+	logger.AsInfo().Write("not sent")
+	logger.AsError().WithField("user", "walrus").With(Int("attempts", 3), Err(errors.New("denied"))).Write("login failed")
+
+	var fields map[string]interface{}
+	require.NoError(t, json.Unmarshal(buffer.Bytes(), &fields))
+	assert.Equal(t, "login failed", fields["msg"])
+	assert.Equal(t, "ERROR", fields["level"])
+	assert.Equal(t, "walrus", fields["user"])
+	assert.Equal(t, float64(3), fields["attempts"])
+	assert.Equal(t, "denied", fields["error"])
+}
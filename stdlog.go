@@ -0,0 +1,109 @@
+package logrus
+
+import (
+	"log"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// RedirectStdLog redirects the default logger of the standard log package to the Logger: every
+// call to the standard logger is written as an entry at the level, through a LineWriter. The
+// fatal and panic levels are written as errors, like with LineWriterOptions.ParseLevel, since
+// the standard logger exits or panics by itself. The standard logger keeps its prefix and flags,
+// which are parsed out of its output: the prefix is added as the `prefix` field, the date and
+// time are dropped since the entries have their own time, and the file and line written with
+// log.Lshortfile or log.Llongfile are reported as the caller of the entry. The prefix and flags
+// are read once, so they must be set before the redirection. The returned function restores the
+// output of the standard logger:
+//
+//	restore := logger.RedirectStdLog(logrus.InfoLevel)
+//	defer restore()
+func (logger *Logger) RedirectStdLog(level Level) func() {
+	if terminates(level) {
+		level = ErrorLevel
+	}
+	w := logger.LineWriter(level, LineWriterOptions{})
+	w.parse = stdLogParser(log.Flags(), log.Prefix())
+	out := log.Writer()
+	log.SetOutput(w)
+	return func() {
+		log.SetOutput(out)
+		w.Close()
+	}
+}
+
+// stdLogParser returns the parse function of the LineWriter the standard logger is redirected
+// to, which takes the prefix and the flags out of the lines
+func stdLogParser(flags int, prefix string) func(entry *Entry, line []byte) (*Entry, []byte) {
+	name := strings.TrimSuffix(strings.TrimSpace(prefix), ":")
+	return func(entry *Entry, line []byte) (*Entry, []byte) {
+		if !entry.isEnabled() {
+			return entry, line
+		}
+
+		message, file, lineNumber := parseStdLog(string(line), flags, prefix)
+		entry = entry.clone(entry.Level, entry.Data)
+		if name != "" {
+			entry = entry.WithField(prefixKey, name)
+		}
+		if entry.Logger.ReportCaller() {
+			if file != "" {
+				entry.writerCaller = &runtime.Frame{File: file, Line: lineNumber}
+			} else {
+				// The caller is the one of the standard logger, not the writer
+				entry.writerCaller = getCallerOutside("log")
+			}
+		}
+		return entry, []byte(message)
+	}
+}
+
+// parseStdLog takes the prefix, the date, the time and the file written by the standard logger
+// with the flags out of the output. It returns the message, and the file and the line if the
+// output has them.
+func parseStdLog(output string, flags int, prefix string) (message string, file string, line int) {
+	message = strings.TrimSuffix(output, "\n")
+	if flags&log.Lmsgprefix == 0 {
+		message = strings.TrimPrefix(message, prefix)
+	}
+	if flags&log.Ldate != 0 && len(message) > 10 && message[10] == ' ' {
+		// 2009/01/23
+		message = message[11:]
+	}
+	if flags&(log.Ltime|log.Lmicroseconds) != 0 {
+		// 01:23:23 or 01:23:23.123123
+		n := 8
+		if flags&log.Lmicroseconds != 0 {
+			n = 15
+		}
+		if len(message) > n && message[n] == ' ' {
+			message = message[n+1:]
+		}
+	}
+	if flags&(log.Lshortfile|log.Llongfile) != 0 {
+		message, file, line = parseStdLogFile(message)
+	}
+	if flags&log.Lmsgprefix != 0 {
+		message = strings.TrimPrefix(message, prefix)
+	}
+	return message, file, line
+}
+
+// parseStdLogFile takes the file and the line, written as "file.go:23: ", out of the message
+func parseStdLogFile(message string) (string, string, int) {
+	for end := 0; end < len(message); {
+		i := strings.Index(message[end:], ": ")
+		if i < 0 {
+			break
+		}
+		end += i
+		if colon := strings.LastIndexByte(message[:end], ':'); colon > 0 {
+			if line, err := strconv.Atoi(message[colon+1 : end]); err == nil && line >= 0 {
+				return message[end+2:], message[:colon], line
+			}
+		}
+		end += 2
+	}
+	return message, "", 0
+}
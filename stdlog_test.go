@@ -0,0 +1,76 @@
+package logrus
+
+import (
+	"fmt"
+	"log"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedirectStdLog(t *testing.T) {
+	var out syncBuffer
+	logger := newRecoverLogger(&out)
+	logger.SetReportCaller(true)
+
+	log.SetPrefix("[db] ")
+	log.SetFlags(log.LstdFlags | log.Lmicroseconds | log.Lshortfile)
+	defer func() {
+		log.SetPrefix("")
+		log.SetFlags(log.LstdFlags)
+	}()
+	restore := logger.RedirectStdLog(WarnLevel)
+	_, _, line, _ := runtime.Caller(0)
+	log.Print("connection lost")
+	restore()
+
+	fields := decodeEntry(t, out.Bytes())
+	assert.Equal(t, "connection lost", fields["msg"])
+	assert.Equal(t, "warning", fields["level"])
+	assert.Equal(t, "[db]", fields["prefix"])
+	assert.Equal(t, fmt.Sprintf("stdlog_test.go:%d", line+1), fields["file"])
+	assert.Nil(t, fields["func"])
+
+	assert.Equal(t, "[db] ", log.Prefix())
+	assert.Equal(t, log.LstdFlags|log.Lmicroseconds|log.Lshortfile, log.Flags())
+}
+
+func TestRedirectStdLogAtFatalLevel(t *testing.T) {
+	var out syncBuffer
+	logger := newRecoverLogger(&out)
+	exited := false
+	logger.SetExitOptions(ExitOptions{ExitFunc: func(int) { exited = true }})
+
+	restore := logger.RedirectStdLog(FatalLevel)
+	log.Print("disk full")
+	restore()
+
+	fields := decodeEntry(t, out.Bytes())
+	assert.Equal(t, "disk full", fields["msg"])
+	assert.Equal(t, "error", fields["level"])
+	assert.False(t, exited)
+}
+
+func TestParseStdLog(t *testing.T) {
+	testCases := []struct {
+		output  string
+		flags   int
+		prefix  string
+		message string
+		file    string
+		line    int
+	}{
+		{"hello\n", 0, "", "hello", "", 0},
+		{"app: 2009/01/23 01:23:23 hello\n", log.LstdFlags, "app: ", "hello", "", 0},
+		{"01:23:23.123123 main.go:12: hello: world\n", log.Lmicroseconds | log.Lshortfile, "", "hello: world", "main.go", 12},
+		{"2009/01/23 C:/src/main.go:7: app: hello\n", log.Ldate | log.Llongfile | log.Lmsgprefix, "app: ", "hello", "C:/src/main.go", 7},
+		{"no file: here\n", log.Lshortfile, "", "no file: here", "", 0},
+	}
+	for _, tc := range testCases {
+		message, file, line := parseStdLog(tc.output, tc.flags, tc.prefix)
+		assert.Equal(t, tc.message, message, tc.output)
+		assert.Equal(t, tc.file, file, tc.output)
+		assert.Equal(t, tc.line, line, tc.output)
+	}
+}
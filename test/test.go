@@ -0,0 +1,218 @@
+// Package test records the entries written by a logrus Logger, so the unit tests can assert
+// on the structured entries instead of matching the formatted output.
+//
+//	logger, hook := test.NewNullLogger()
+//	logger.AsError().WithField("user", "walrus").Write("access denied")
+//
+//	hook.AssertLogged(t, logrus.ErrorLevel, "access denied")
+//	hook.AssertField(t, "user", "walrus")
+package test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/xitonix/logrus"
+)
+
+// Hook is a hook designed for dealing with logs in test scenarios. It records a copy of every
+// entry it's fired for. All the methods are safe for concurrent use.
+type Hook struct {
+	// Entries is an array of all entries that have been received by this hook.
+	// For safe access, use the AllEntries() method, rather than reading this
+	// value directly.
+	Entries []logrus.Entry
+	mu      sync.RWMutex
+}
+
+// NewGlobal installs a test hook for the global logger.
+func NewGlobal() *Hook {
+	hook := new(Hook)
+	logrus.AddHook(hook)
+	return hook
+}
+
+// NewLocal installs a test hook for a given local logger.
+func NewLocal(logger *logrus.Logger) *Hook {
+	hook := new(Hook)
+	logger.AddHook(hook)
+	return hook
+}
+
+// NewNullLogger creates a discarding logger at the most verbose level and installs the test
+// hook.
+func NewNullLogger() (*logrus.Logger, *Hook) {
+	logger := logrus.New(logrus.TraceLevel)
+	logger.SetOutput(ioutil.Discard)
+	return logger, NewLocal(logger)
+}
+
+// Fire records a copy of the entry. The fields are copied, since the Logger may reuse the entry.
+func (t *Hook) Fire(e *logrus.Entry) error {
+	entry := *e
+	entry.Data = make(logrus.Fields, len(e.Data))
+	for k, v := range e.Data {
+		entry.Data[k] = v
+	}
+	if e.Typed != nil {
+		entry.Typed = append([]logrus.Field(nil), e.Typed...)
+	}
+	if e.Caller != nil {
+		caller := *e.Caller
+		entry.Caller = &caller
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.Entries = append(t.Entries, entry)
+	return nil
+}
+
+// Levels returns all the levels, so the hook records every entry the Logger writes.
+func (t *Hook) Levels() []logrus.Level {
+	return logrus.Levels()
+}
+
+// LastEntry returns the last entry that was logged or nil.
+func (t *Hook) LastEntry() *logrus.Entry {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	i := len(t.Entries) - 1
+	if i < 0 {
+		return nil
+	}
+	entry := t.Entries[i]
+	return &entry
+}
+
+// AllEntries returns all entries that were logged.
+func (t *Hook) AllEntries() []*logrus.Entry {
+	return t.EntriesAt(logrus.Levels()...)
+}
+
+// EntriesAt returns the entries that were logged at any of the levels.
+func (t *Hook) EntriesAt(levels ...logrus.Level) []*logrus.Entry {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	// Make a copy so the returned value won't race with future log requests
+	entries := make([]*logrus.Entry, 0, len(t.Entries))
+	for i := range t.Entries {
+		entry := t.Entries[i]
+		if hasLevel(levels, entry.Level) {
+			entries = append(entries, &entry)
+		}
+	}
+	return entries
+}
+
+// Reset removes all Entries from this test hook.
+func (t *Hook) Reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.Entries = make([]logrus.Entry, 0)
+}
+
+// AssertLogged reports an error to tb unless an entry was logged at the level with the message.
+func (t *Hook) AssertLogged(tb testing.TB, level logrus.Level, message string) bool {
+	tb.Helper()
+	if t.find(level, message) == nil {
+		tb.Errorf("no %s entry was logged with the message %q\n%s", level, message, t.dump())
+		return false
+	}
+	return true
+}
+
+// AssertNotLogged reports an error to tb if an entry was logged at the level with the message.
+func (t *Hook) AssertNotLogged(tb testing.TB, level logrus.Level, message string) bool {
+	tb.Helper()
+	if t.find(level, message) != nil {
+		tb.Errorf("a %s entry was logged with the message %q", level, message)
+		return false
+	}
+	return true
+}
+
+// AssertCount reports an error to tb unless count entries were logged at any of the levels, or at
+// all the levels if none is given.
+func (t *Hook) AssertCount(tb testing.TB, count int, levels ...logrus.Level) bool {
+	tb.Helper()
+	if len(levels) == 0 {
+		levels = logrus.Levels()
+	}
+	if actual := len(t.EntriesAt(levels...)); actual != count {
+		tb.Errorf("expected %d entries to be logged at %v, got %d\n%s", count, levels, actual, t.dump())
+		return false
+	}
+	return true
+}
+
+// AssertField reports an error to tb unless the last entry has the field with the expected value.
+// The typed fields are compared by their Value.
+func (t *Hook) AssertField(tb testing.TB, key string, expected interface{}) bool {
+	tb.Helper()
+	entry := t.LastEntry()
+	if entry == nil {
+		tb.Errorf("no entry was logged")
+		return false
+	}
+	actual, ok := fieldValue(entry, key)
+	if !ok {
+		tb.Errorf("the last entry has no %q field", key)
+		return false
+	}
+	if !reflect.DeepEqual(expected, actual) {
+		tb.Errorf("the %q field of the last entry is %#v, expected %#v", key, actual, expected)
+		return false
+	}
+	return true
+}
+
+// find returns a copy of the last entry logged at the level with the message, or nil
+func (t *Hook) find(level logrus.Level, message string) *logrus.Entry {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	for i := len(t.Entries) - 1; i >= 0; i-- {
+		if entry := t.Entries[i]; entry.Level == level && entry.Message == message {
+			return &entry
+		}
+	}
+	return nil
+}
+
+// dump lists the recorded entries for the failure messages
+func (t *Hook) dump() string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	if len(t.Entries) == 0 {
+		return "no entries were logged"
+	}
+	s := "logged entries:"
+	for _, entry := range t.Entries {
+		s += fmt.Sprintf("\n\t%s: %q", entry.Level, entry.Message)
+	}
+	return s
+}
+
+// fieldValue returns the value of the field, looking up the typed fields first since they take
+// precedence over Data
+func fieldValue(entry *logrus.Entry, key string) (interface{}, bool) {
+	for i := len(entry.Typed) - 1; i >= 0; i-- {
+		if entry.Typed[i].Key == key {
+			return entry.Typed[i].Value(), true
+		}
+	}
+	value, ok := entry.Data[key]
+	return value, ok
+}
+
+func hasLevel(levels []logrus.Level, level logrus.Level) bool {
+	for _, l := range levels {
+		if l == level {
+			return true
+		}
+	}
+	return false
+}
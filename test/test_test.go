@@ -0,0 +1,125 @@
+package test
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xitonix/logrus"
+)
+
+func TestAllHooks(t *testing.T) {
+	logger, hook := NewNullLogger()
+	assert.Nil(t, hook.LastEntry())
+	assert.Empty(t, hook.AllEntries())
+
+	logger.Error("Hello error")
+	require.NotNil(t, hook.LastEntry())
+	assert.Equal(t, logrus.ErrorLevel, hook.LastEntry().Level)
+	assert.Equal(t, "Hello error", hook.LastEntry().Message)
+	assert.Len(t, hook.Entries, 1)
+
+	logger.Warning("Hello warning")
+	assert.Equal(t, logrus.WarnLevel, hook.LastEntry().Level)
+	assert.Equal(t, "Hello warning", hook.LastEntry().Message)
+	assert.Len(t, hook.AllEntries(), 2)
+
+	hook.Reset()
+	assert.Nil(t, hook.LastEntry())
+	assert.Empty(t, hook.AllEntries())
+
+	hook = NewGlobal()
+	logrus.Error("Hello error")
+	assert.Equal(t, logrus.ErrorLevel, hook.LastEntry().Level)
+	assert.Equal(t, "Hello error", hook.LastEntry().Message)
+	assert.Len(t, hook.Entries, 1)
+}
+
+func TestEntriesAreCopied(t *testing.T) {
+	logger, hook := NewNullLogger()
+
+	fields := logrus.Fields{"key": "value"}
+	logger.AsInfo().WithFields(fields).With(logrus.Int("count", 1)).Write("test")
+	fields["key"] = "changed"
+
+	entry := hook.LastEntry()
+	require.NotNil(t, entry)
+	assert.Equal(t, "value", entry.Data["key"])
+	assert.Equal(t, int64(1), entry.Typed[0].Value())
+	assert.False(t, entry.Time.IsZero())
+}
+
+func TestEntriesAt(t *testing.T) {
+	logger, hook := NewNullLogger()
+	logger.Debug("debug")
+	logger.Info("info")
+	logger.Error("first error")
+	logger.Error("second error")
+
+	errors := hook.EntriesAt(logrus.ErrorLevel)
+	require.Len(t, errors, 2)
+	assert.Equal(t, "first error", errors[0].Message)
+	assert.Equal(t, "second error", errors[1].Message)
+	assert.Len(t, hook.EntriesAt(logrus.DebugLevel, logrus.InfoLevel), 2)
+	assert.Empty(t, hook.EntriesAt(logrus.PanicLevel))
+}
+
+type recordingT struct {
+	testing.TB
+	errors []string
+}
+
+func (t *recordingT) Helper() {}
+
+func (t *recordingT) Errorf(format string, args ...interface{}) {
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
+
+func TestAssertions(t *testing.T) {
+	logger, hook := NewNullLogger()
+	logger.AsWarning().WithField("user", "walrus").With(logrus.Int("attempts", 3)).Write("access denied")
+
+	passing := &recordingT{}
+	assert.True(t, hook.AssertLogged(passing, logrus.WarnLevel, "access denied"))
+	assert.True(t, hook.AssertNotLogged(passing, logrus.ErrorLevel, "access denied"))
+	assert.True(t, hook.AssertCount(passing, 1))
+	assert.True(t, hook.AssertCount(passing, 0, logrus.ErrorLevel))
+	assert.True(t, hook.AssertField(passing, "user", "walrus"))
+	assert.True(t, hook.AssertField(passing, "attempts", int64(3)))
+	assert.Empty(t, passing.errors)
+
+	failing := &recordingT{}
+	assert.False(t, hook.AssertLogged(failing, logrus.ErrorLevel, "access denied"))
+	assert.False(t, hook.AssertNotLogged(failing, logrus.WarnLevel, "access denied"))
+	assert.False(t, hook.AssertCount(failing, 2))
+	assert.False(t, hook.AssertField(failing, "user", "seal"))
+	assert.False(t, hook.AssertField(failing, "missing", nil))
+	require.Len(t, failing.errors, 5)
+	assert.Contains(t, failing.errors[0], `warning: "access denied"`)
+
+	hook.Reset()
+	assert.False(t, hook.AssertField(failing, "user", "walrus"))
+}
+
+func TestConcurrentRecording(t *testing.T) {
+	logger, hook := NewNullLogger()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				logger.AsInfo().WithField("goroutine", i).Write("test")
+				hook.LastEntry()
+				hook.AllEntries()
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	assert.Len(t, hook.AllEntries(), 1000)
+	hook.AssertCount(t, 1000, logrus.InfoLevel)
+}
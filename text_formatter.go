@@ -5,7 +5,9 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"runtime"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -57,6 +59,16 @@ type TextFormatter struct {
 	// QuoteEmptyFields will wrap empty fields in quotes if true
 	QuoteEmptyFields bool
 
+	// CallerPrettyfier can be set by the user to modify the content of the
+	// function and file keys in the output when the Logger is reporting the
+	// caller. If either of the returned values is the empty string, the
+	// corresponding key will be omitted.
+	CallerPrettyfier func(*runtime.Frame) (function string, file string)
+
+	// ErrorFormat tells how the fields holding an error are written. By default, only the
+	// message of the errors is written.
+	ErrorFormat ErrorFormat
+
 	// Whether the Logger's Out is to a terminal
 	isTerminal bool
 
@@ -65,22 +77,29 @@ type TextFormatter struct {
 
 // Format renders a single log entry
 func (f *TextFormatter) Format(entry *Entry) ([]byte, error) {
+	return formatCopy(f, entry)
+}
+
+func (f *TextFormatter) appendFormat(dst []byte, entry *Entry) ([]byte, error) {
 	f.Do(func() {
 		if entry.Logger != nil {
 			f.init(entry.Logger.Out)
 		}
 	})
-	b := &bytes.Buffer{}
-	keys := make([]string, 0, len(entry.Data))
-	for k := range entry.Data {
-		keys = append(keys, k)
-	}
+	enc := newFormatEncoder()
+	defer enc.release()
+	b := &enc.text
+	enc.fields = appendFieldRefs(enc.fields[:0], entry, true)
+	keys := enc.fields
 
 	if !f.DisableSorting {
-		sort.Strings(keys)
+		sort.Sort(enc)
 	}
 
-	prefixFieldClashes(entry.Data)
+	var funcVal, fileVal string
+	if entry.Caller != nil {
+		funcVal, fileVal = callerFields(entry.Caller, f.CallerPrettyfier)
+	}
 
 	isColored := (f.ForceColors || f.isTerminal) && !f.DisableColors
 
@@ -88,23 +107,37 @@ func (f *TextFormatter) Format(entry *Entry) ([]byte, error) {
 	if timestampFormat == "" {
 		timestampFormat = defaultTimestampFormat
 	}
+	var details []namedError
 	if isColored {
-		f.printColored(b, entry.Level, entry.Message, entry.Time, entry.Data, keys, timestampFormat)
+		details = f.printColored(b, entry, keys, timestampFormat, funcVal, fileVal)
 	} else {
-		if !f.DisableTimestamp {
-			f.appendKeyValue(b, timeKey, entry.Time.Format(timestampFormat))
+		if !f.DisableTimestamp && entry.hasTime() {
+			f.appendKey(b, timeKey)
+			var scratch [64]byte
+			f.appendBytes(b, entry.Time.AppendFormat(scratch[:0], timestampFormat))
 		}
 		f.appendKeyValue(b, levelKey, entry.Level.String())
 		if len(entry.Message) > 0 {
 			f.appendKeyValue(b, messageKey, entry.Message)
 		}
+		if funcVal != "" {
+			f.appendKeyValue(b, funcKey, funcVal)
+		}
+		if fileVal != "" {
+			f.appendKeyValue(b, fileKey, fileVal)
+		}
 		for _, key := range keys {
-			f.appendKeyValue(b, key, entry.Data[key])
+			f.appendKey(b, key.name)
+			f.appendFieldValue(b, entry, key)
+			details = f.appendErrorDetails(b, entry, key, nocolor, details)
 		}
 	}
 
 	b.WriteByte('\n')
-	return b.Bytes(), nil
+	for _, detail := range details {
+		writeMultilineError(b, detail.key, detail.node, "  ")
+	}
+	return append(dst, b.Bytes()...), nil
 }
 
 func (f *TextFormatter) init(w io.Writer) {
@@ -120,33 +153,50 @@ func (f *TextFormatter) checkIfTerminal(w io.Writer) bool {
 	}
 }
 
-func (f *TextFormatter) printColored(b *bytes.Buffer, level Level, message string, t time.Time, fields Fields, keys []string, timestampFormat string) {
+func (f *TextFormatter) printColored(b *bytes.Buffer, entry *Entry, keys fieldRefs, timestampFormat string, funcVal string, fileVal string) []namedError {
+	level, message, t := entry.Level, entry.Message, entry.Time
 	var levelColor int
-	switch level {
-	case DebugLevel:
-		levelColor = gray
-	case WarnLevel:
-		levelColor = yellow
-	case ErrorLevel, FatalLevel, PanicLevel:
+	// The registered levels get the color of the next predefined level
+	switch {
+	case ErrorLevel.Enables(level):
 		levelColor = red
-	default:
+	case WarnLevel.Enables(level):
+		levelColor = yellow
+	case InfoLevel.Enables(level):
 		levelColor = blue
+	case DebugLevel.Enables(level):
+		levelColor = gray
+	default:
+		levelColor = green
+	}
+
+	levelText := strings.ToUpper(level.String())
+	if len(levelText) > 4 {
+		levelText = levelText[0:4]
 	}
 
-	levelText := strings.ToUpper(level.String())[0:4]
+	caller := ""
+	if funcVal != "" {
+		caller = " " + funcVal + "()"
+	}
+	if fileVal != "" {
+		caller += " " + fileVal
+	}
 
-	if f.DisableTimestamp {
-		fmt.Fprintf(b, "\x1b[%dm%s\x1b[0m %-44s ", levelColor, levelText, message)
+	if f.DisableTimestamp || !entry.hasTime() {
+		fmt.Fprintf(b, "\x1b[%dm%-4s\x1b[0m%s %-44s ", levelColor, levelText, caller, message)
 	} else if !f.FullTimestamp {
-		fmt.Fprintf(b, "\x1b[%dm%s\x1b[0m[%04d] %-44s ", levelColor, levelText, int(t.Sub(baseTimestamp)/time.Second), message)
+		fmt.Fprintf(b, "\x1b[%dm%-4s\x1b[0m[%04d]%s %-44s ", levelColor, levelText, int(t.Sub(baseTimestamp)/time.Second), caller, message)
 	} else {
-		fmt.Fprintf(b, "\x1b[%dm%s\x1b[0m[%s] %-44s ", levelColor, levelText, t.Format(timestampFormat), message)
+		fmt.Fprintf(b, "\x1b[%dm%-4s\x1b[0m[%s]%s %-44s ", levelColor, levelText, t.Format(timestampFormat), caller, message)
 	}
+	var details []namedError
 	for _, k := range keys {
-		v := fields[k]
-		fmt.Fprintf(b, " \x1b[%dm%s\x1b[0m=", levelColor, k)
-		f.appendValue(b, v)
+		fmt.Fprintf(b, " \x1b[%dm%s\x1b[0m=", levelColor, k.name)
+		f.appendFieldValue(b, entry, k)
+		details = f.appendErrorDetails(b, entry, k, levelColor, details)
 	}
+	return details
 }
 
 func (f *TextFormatter) needsQuoting(text string) bool {
@@ -154,23 +204,32 @@ func (f *TextFormatter) needsQuoting(text string) bool {
 		return true
 	}
 	for _, ch := range text {
-		if !((ch >= 'a' && ch <= 'z') ||
-			(ch >= 'A' && ch <= 'Z') ||
-			(ch >= '0' && ch <= '9') ||
-			ch == '-' || ch == '.' || ch == '_' || ch == '/' || ch == '@' || ch == '^' || ch == '+') {
+		if !isSafeTextRune(ch) {
 			return true
 		}
 	}
 	return false
 }
 
-func (f *TextFormatter) appendKeyValue(b *bytes.Buffer, key string, value interface{}) {
+// isSafeTextRune returns true if the rune can be written without quoting the value
+func isSafeTextRune(ch rune) bool {
+	return (ch >= 'a' && ch <= 'z') ||
+		(ch >= 'A' && ch <= 'Z') ||
+		(ch >= '0' && ch <= '9') ||
+		ch == '-' || ch == '.' || ch == '_' || ch == '/' || ch == '@' || ch == '^' || ch == '+'
+}
+
+func (f *TextFormatter) appendKey(b *bytes.Buffer, key string) {
 	if b.Len() > 0 {
 		b.WriteByte(' ')
 	}
 	b.WriteString(key)
 	b.WriteByte('=')
-	f.appendValue(b, value)
+}
+
+func (f *TextFormatter) appendKeyValue(b *bytes.Buffer, key string, value string) {
+	f.appendKey(b, key)
+	f.appendString(b, value)
 }
 
 func (f *TextFormatter) appendValue(b *bytes.Buffer, value interface{}) {
@@ -178,10 +237,52 @@ func (f *TextFormatter) appendValue(b *bytes.Buffer, value interface{}) {
 	if !ok {
 		stringVal = fmt.Sprint(value)
 	}
+	f.appendString(b, stringVal)
+}
 
+// appendFieldValue appends the value of a field of Data, or of a typed field without boxing it
+func (f *TextFormatter) appendFieldValue(b *bytes.Buffer, entry *Entry, key fieldRef) {
+	if key.typed < 0 {
+		f.appendValue(b, entry.Data[key.key])
+		return
+	}
+	field := &entry.Typed[key.typed]
+	if field.Type == StringType {
+		f.appendString(b, field.String)
+		return
+	}
+	var scratch [64]byte
+	f.appendBytes(b, field.appendText(scratch[:0]))
+}
+
+func (f *TextFormatter) appendString(b *bytes.Buffer, stringVal string) {
 	if !f.needsQuoting(stringVal) {
 		b.WriteString(stringVal)
 	} else {
-		b.WriteString(fmt.Sprintf("%q", stringVal))
+		var scratch [64]byte
+		b.Write(strconv.AppendQuote(scratch[:0], stringVal))
+	}
+}
+
+// appendBytes appends a value formatted into a scratch buffer like appendString, without
+// converting it to a string unless it has to be escaped
+func (f *TextFormatter) appendBytes(b *bytes.Buffer, value []byte) {
+	quote, escape := f.QuoteEmptyFields && len(value) == 0, false
+	// Ranging over the converted bytes doesn't allocate
+	for _, ch := range string(value) {
+		if !isSafeTextRune(ch) {
+			quote = true
+			escape = escape || ch == '"' || ch == '\\' || ch < ' ' || ch > '~'
+		}
+	}
+	switch {
+	case escape:
+		f.appendString(b, string(value))
+	case quote:
+		b.WriteByte('"')
+		b.Write(value)
+		b.WriteByte('"')
+	default:
+		b.Write(value)
 	}
 }
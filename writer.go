@@ -1,62 +1,208 @@
 package logrus
 
 import (
-	"bufio"
+	"bytes"
 	"io"
-	"runtime"
+	"strings"
+	"sync"
+	"unicode/utf8"
 )
 
-func (logger *Logger) Writer() *io.PipeWriter {
+// defaultMaxLineLength is the length of the lines above which a LineWriter splits them, the
+// maximum length of the lines of bufio.Scanner
+const defaultMaxLineLength = 64 * 1024
+
+// truncatedLineMarker ends the lines truncated by a LineWriter
+const truncatedLineMarker = "...(truncated)"
+
+// LineWriterOptions configures a LineWriter.
+type LineWriterOptions struct {
+	// MaxLineLength is the maximum length of the lines in bytes. The longer lines are split into
+	// several entries, or truncated if Truncate is set. The default is 64KB.
+	MaxLineLength int
+
+	// Truncate writes the beginning of the long lines followed by "...(truncated)" and drops the
+	// rest, instead of splitting them.
+	Truncate bool
+
+	// ParseLevel reads the level of each line from its prefix, like "[WARN]" or "error:", which
+	// is removed from the message. The lines without a prefix are written at the level of the
+	// writer. The fatal and panic levels are written as errors, so the lines never exit nor
+	// panic.
+	ParseLevel bool
+}
+
+// LineWriter is an io.WriteCloser writing every line written to it as an entry. The lines are
+// written synchronously by Write, and the last line, which has no line break, by Flush or Close.
+// It's safe for concurrent use.
+type LineWriter struct {
+	entry *Entry
+	opts  LineWriterOptions
+
+	mux    sync.Mutex
+	buf    []byte
+	closed bool
+	// truncated is set while the rest of a truncated line is dropped
+	truncated bool
+	// parse, if set, takes what the program writing the lines adds to them, like the prefix
+	// and the flags of the standard logger, out of the lines and into the entries
+	parse func(entry *Entry, line []byte) (*Entry, []byte)
+}
+
+func (logger *Logger) Writer() *LineWriter {
 	return logger.WriterLevel(InfoLevel)
 }
 
-func (logger *Logger) WriterLevel(level Level) *io.PipeWriter {
-	return NewEntry(logger).WriterLevel(level)
+func (logger *Logger) WriterLevel(level Level) *LineWriter {
+	return NewEntry(logger).LineWriter(level, LineWriterOptions{})
+}
+
+// LineWriter creates a writer writing the lines at the level with the options.
+func (logger *Logger) LineWriter(level Level, opts LineWriterOptions) *LineWriter {
+	return NewEntry(logger).LineWriter(level, opts)
 }
 
-func (entry *Entry) Writer() *io.PipeWriter {
+func (entry *Entry) Writer() *LineWriter {
 	return entry.WriterLevel(InfoLevel)
 }
 
-func (entry *Entry) WriterLevel(level Level) *io.PipeWriter {
-	reader, writer := io.Pipe()
+func (entry *Entry) WriterLevel(level Level) *LineWriter {
+	return entry.LineWriter(level, LineWriterOptions{})
+}
+
+// LineWriter creates a writer writing the lines at the level with the options, with the fields
+// of the entry.
+func (entry *Entry) LineWriter(level Level, opts LineWriterOptions) *LineWriter {
+	if opts.MaxLineLength <= 0 {
+		opts.MaxLineLength = defaultMaxLineLength
+	}
+	logEntry := entry.AsLevel(level)
+	// The lines are written by the callers of Write, which are rarely the interesting ones:
+	// the caller of LineWriter is reported instead
+	logEntry.writerCaller = getCaller()
+	return &LineWriter{entry: logEntry, opts: opts}
+}
 
-	var printFunc func(args ...interface{})
+// Write writes the complete lines of p, and buffers the last one until its line break is
+// written. It fails with io.ErrClosedPipe once the writer is closed.
+func (w *LineWriter) Write(p []byte) (int, error) {
+	w.mux.Lock()
+	defer w.mux.Unlock()
+	if w.closed {
+		return 0, io.ErrClosedPipe
+	}
 
-	switch level {
-	case DebugLevel:
-		printFunc = entry.AsDebug().Write
-	case InfoLevel:
-		printFunc = entry.AsInfo().Write
-	case WarnLevel:
-		printFunc = entry.AsWarning().Write
-	case ErrorLevel:
-		printFunc = entry.AsError().Write
-	case FatalLevel:
-		printFunc = entry.AsFatal().Write
-	case PanicLevel:
-		printFunc = entry.AsPanic().Write
-	default:
-		printFunc = entry.AsInfo().Write
+	n := len(p)
+	for len(p) > 0 {
+		i := bytes.IndexByte(p, '\n')
+		if i < 0 {
+			w.buffer(p)
+			break
+		}
+		w.buffer(p[:i])
+		if !w.truncated {
+			w.writeLine(w.buf)
+		}
+		w.buf = w.buf[:0]
+		w.truncated = false
+		p = p[i+1:]
 	}
+	return n, nil
+}
 
-	go entry.writerScanner(reader, printFunc)
-	runtime.SetFinalizer(writer, writerFinalizer)
+// buffer appends the part of a line to the buffer, splitting or truncating it when it's too long
+func (w *LineWriter) buffer(p []byte) {
+	if w.truncated {
+		return
+	}
+	w.buf = append(w.buf, p...)
+	for len(w.buf) > w.opts.MaxLineLength {
+		end := w.opts.MaxLineLength
+		// Don't split the runes
+		for i := end; i > end-utf8.UTFMax && i > 0; i-- {
+			if utf8.RuneStart(w.buf[i]) {
+				end = i
+				break
+			}
+		}
+		if w.opts.Truncate {
+			w.writeLine(append(w.buf[:end:end], truncatedLineMarker...))
+			w.buf = w.buf[:0]
+			w.truncated = true
+			return
+		}
+		w.writeLine(w.buf[:end])
+		w.buf = append(w.buf[:0], w.buf[end:]...)
+	}
+}
 
-	return writer
+func (w *LineWriter) writeLine(line []byte) {
+	line = bytes.TrimSuffix(line, []byte{'\r'})
+	entry := w.entry
+	if w.parse != nil {
+		entry, line = w.parse(entry, line)
+	}
+	if w.opts.ParseLevel {
+		var level Level
+		var ok bool
+		if level, line, ok = parseLevelPrefix(line); ok {
+			if terminates(level) {
+				level = ErrorLevel
+			}
+			caller := entry.writerCaller
+			entry = entry.AsLevel(level)
+			entry.writerCaller = caller
+		}
+	}
+	entry.Write(string(line))
 }
 
-func (entry *Entry) writerScanner(reader *io.PipeReader, printFunc func(args ...interface{})) {
-	scanner := bufio.NewScanner(reader)
-	for scanner.Scan() {
-		printFunc(scanner.Text())
+// parseLevelPrefix parses the level prefix of the line, "[level]" or "level:", and returns the
+// rest of the line without the spaces following the prefix
+func parseLevelPrefix(line []byte) (Level, []byte, bool) {
+	var name, rest []byte
+	if len(line) > 0 && line[0] == '[' {
+		end := bytes.IndexByte(line, ']')
+		if end < 0 {
+			return 0, line, false
+		}
+		name, rest = line[1:end], line[end+1:]
+	} else {
+		end := bytes.IndexByte(line, ':')
+		if end < 0 || bytes.IndexByte(line[:end], ' ') >= 0 {
+			return 0, line, false
+		}
+		name, rest = line[:end], line[end+1:]
 	}
-	if err := scanner.Err(); err != nil {
-		entry.AsError().Write("Error while reading from Writer: %s", err)
+	level, err := ParseLevel(strings.TrimSpace(string(name)))
+	if err != nil {
+		return 0, line, false
 	}
-	reader.Close()
+	return level, bytes.TrimLeft(rest, " \t"), true
+}
+
+// Flush writes the last line, even though its line break hasn't been written yet.
+func (w *LineWriter) Flush() error {
+	w.mux.Lock()
+	defer w.mux.Unlock()
+	w.flush()
+	return nil
 }
 
-func writerFinalizer(writer *io.PipeWriter) {
-	writer.Close()
+func (w *LineWriter) flush() {
+	if len(w.buf) > 0 {
+		w.writeLine(w.buf)
+		w.buf = w.buf[:0]
+	}
+}
+
+// Close writes the last line, if any, and closes the writer. Closing it again does nothing.
+func (w *LineWriter) Close() error {
+	w.mux.Lock()
+	defer w.mux.Unlock()
+	if !w.closed {
+		w.flush()
+		w.closed = true
+	}
+	return nil
 }
@@ -0,0 +1,99 @@
+package logrus
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writtenEntries returns the level and the message of the entries written in JSON
+func writtenEntries(t *testing.T, buffer *bytes.Buffer) [][2]string {
+	var entries [][2]string
+	for _, line := range strings.Split(strings.TrimSpace(buffer.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		fields := decodeEntry(t, []byte(line))
+		entries = append(entries, [2]string{fields[levelKey].(string), fields[messageKey].(string)})
+	}
+	return entries
+}
+
+func newWriterLogger(buffer *bytes.Buffer) *Logger {
+	logger := New(TraceLevel)
+	logger.SetOutput(buffer)
+	logger.SetFormatter(new(JSONFormatter))
+	return logger
+}
+
+func TestLineWriterPartialLines(t *testing.T) {
+	var buffer bytes.Buffer
+	w := newWriterLogger(&buffer).WriterLevel(InfoLevel)
+
+	_, err := w.Write([]byte("hel"))
+	require.NoError(t, err)
+	assert.Empty(t, buffer.String())
+
+	w.Write([]byte("lo\r\nwor"))
+	assert.Equal(t, [][2]string{{"info", "hello"}}, writtenEntries(t, &buffer))
+
+	require.NoError(t, w.Flush())
+	w.Write([]byte("ld"))
+	require.NoError(t, w.Close())
+	assert.Equal(t, [][2]string{{"info", "hello"}, {"info", "wor"}, {"info", "ld"}}, writtenEntries(t, &buffer))
+
+	_, err = w.Write([]byte("closed\n"))
+	assert.Equal(t, io.ErrClosedPipe, err)
+	assert.NoError(t, w.Close())
+}
+
+func TestLineWriterSplitsLongLines(t *testing.T) {
+	var buffer bytes.Buffer
+	w := newWriterLogger(&buffer).LineWriter(InfoLevel, LineWriterOptions{MaxLineLength: 4})
+
+	w.Write([]byte("abcdefghij\n"))
+	// The rune é is two bytes long and isn't split
+	w.Write([]byte("abcé\n"))
+	assert.Equal(t, [][2]string{
+		{"info", "abcd"}, {"info", "efgh"}, {"info", "ij"},
+		{"info", "abc"}, {"info", "é"},
+	}, writtenEntries(t, &buffer))
+}
+
+func TestLineWriterTruncatesLongLines(t *testing.T) {
+	var buffer bytes.Buffer
+	w := newWriterLogger(&buffer).LineWriter(InfoLevel, LineWriterOptions{MaxLineLength: 4, Truncate: true})
+
+	w.Write([]byte("abcdef"))
+	w.Write([]byte("ghij\nabc\n"))
+	assert.Equal(t, [][2]string{{"info", "abcd...(truncated)"}, {"info", "abc"}}, writtenEntries(t, &buffer))
+}
+
+func TestLineWriterDefaultMaxLineLength(t *testing.T) {
+	var buffer bytes.Buffer
+	w := newWriterLogger(&buffer).WriterLevel(InfoLevel)
+
+	w.Write([]byte(strings.Repeat("a", 100*1024) + "\n"))
+	entries := writtenEntries(t, &buffer)
+	require.Len(t, entries, 2)
+	assert.Len(t, entries[0][1], 64*1024)
+	assert.Len(t, entries[1][1], 36*1024)
+}
+
+func TestLineWriterParsesLevels(t *testing.T) {
+	var buffer bytes.Buffer
+	w := newWriterLogger(&buffer).LineWriter(InfoLevel, LineWriterOptions{ParseLevel: true})
+
+	w.Write([]byte("[WARN] disk almost full\nerror: disk full\n[FATAL] gave up\nhttp: closed\n[debug]\n"))
+	assert.Equal(t, [][2]string{
+		{"warning", "disk almost full"},
+		{"error", "disk full"},
+		{"error", "gave up"},
+		{"info", "http: closed"},
+		{"debug", ""},
+	}, writtenEntries(t, &buffer))
+}